@@ -0,0 +1,269 @@
+// Package billing computes billable amounts and invoices from toggl time entries.
+package billing
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+// RateSource tells which setting an hourly rate was resolved from.
+type RateSource string
+
+// Settings an hourly rate is resolved from, by priority.
+const (
+	RateProjectUser   RateSource = "project_user"
+	RateWorkspaceUser RateSource = "workspace_user"
+	RateWorkspace     RateSource = "workspace"
+	RateNone          RateSource = "none"
+)
+
+// Options holds the settings the amounts are computed from.
+type Options struct {
+	// Workspaces of the time entries, giving the default rates, currencies and rounding.
+	Workspaces []toggl.Workspace
+	// WorkspaceUsers give the rates of the users within their workspace.
+	WorkspaceUsers []toggl.WorkspaceUser
+	// ProjectUsers give the rates of the users within a project.
+	ProjectUsers []toggl.User
+	// Projects resolve the clients of the entries.
+	Projects []toggl.Project
+	// Now ends the running entries. Defaults to time.Now.
+	Now func() time.Time
+}
+
+func (o *Options) withDefaults() Options {
+	opts := Options{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return opts
+}
+
+// Line is a billed time entry.
+type Line struct {
+	Entry toggl.TimeEntry
+	// Duration is the tracked time after the workspace rounding.
+	Duration time.Duration
+	// Rate is the hourly rate, in currency units.
+	Rate     int
+	Source   RateSource
+	Currency string
+	// Amount is the billed amount, in hundredths of the currency.
+	Amount int64
+}
+
+// ProjectTotal sums the lines of a project.
+type ProjectTotal struct {
+	Pid      int
+	Currency string
+	Duration time.Duration
+	Amount   int64
+}
+
+// ClientTotal sums the lines of a client, time without client being counted under 0.
+type ClientTotal struct {
+	Cid      int
+	Currency string
+	Duration time.Duration
+	Amount   int64
+	Projects []ProjectTotal
+}
+
+// Invoice holds the billed lines with their totals per client, project and currency.
+type Invoice struct {
+	Lines   []Line
+	Clients []ClientTotal
+	// Totals maps currencies to the billed amount.
+	Totals map[string]int64
+}
+
+// Calculate bills the billable time entries. Entries of workspaces missing
+// from the options are an error.
+func Calculate(timeEntries []toggl.TimeEntry, options *Options) (*Invoice, error) {
+	opts := options.withDefaults()
+	now := opts.Now()
+	r := newResolver(opts)
+
+	invoice := &Invoice{
+		Lines:   []Line{},
+		Clients: []ClientTotal{},
+		Totals:  map[string]int64{},
+	}
+	for _, te := range timeEntries {
+		if !te.Billable {
+			continue
+		}
+		w, ok := r.workspaces[te.Wid]
+		if !ok {
+			return nil, fmt.Errorf("time entry %d: unknown workspace %d", te.ID, te.Wid)
+		}
+
+		rate, source := r.rate(te)
+		d := w.RoundDuration(te.Elapsed(now))
+		invoice.Lines = append(invoice.Lines, Line{
+			Entry:    te,
+			Duration: d,
+			Rate:     rate,
+			Source:   source,
+			Currency: w.DefaultCurrency,
+			Amount:   Amount(d, rate),
+		})
+	}
+
+	type clientKey struct {
+		cid      int
+		currency string
+	}
+	clients := map[clientKey]int{}
+	for _, l := range invoice.Lines {
+		key := clientKey{r.clients[l.Entry.Pid], l.Currency}
+		i, ok := clients[key]
+		if !ok {
+			i = len(invoice.Clients)
+			clients[key] = i
+			invoice.Clients = append(invoice.Clients, ClientTotal{Cid: key.cid, Currency: key.currency, Projects: []ProjectTotal{}})
+		}
+		c := &invoice.Clients[i]
+		c.Duration += l.Duration
+		c.Amount += l.Amount
+
+		j := 0
+		for ; j < len(c.Projects) && c.Projects[j].Pid != l.Entry.Pid; j++ {
+		}
+		if j == len(c.Projects) {
+			c.Projects = append(c.Projects, ProjectTotal{Pid: l.Entry.Pid, Currency: l.Currency})
+		}
+		c.Projects[j].Duration += l.Duration
+		c.Projects[j].Amount += l.Amount
+
+		invoice.Totals[l.Currency] += l.Amount
+	}
+
+	sort.SliceStable(invoice.Clients, func(i, j int) bool {
+		a, b := invoice.Clients[i], invoice.Clients[j]
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+		return a.Cid < b.Cid
+	})
+	for _, c := range invoice.Clients {
+		sort.SliceStable(c.Projects, func(i, j int) bool {
+			return c.Projects[i].Pid < c.Projects[j].Pid
+		})
+	}
+
+	return invoice, nil
+}
+
+// Amount returns the price of d at the hourly rate, in hundredths of the currency,
+// rounded to the nearest hundredth.
+func Amount(d time.Duration, rate int) int64 {
+	return (int64(d/time.Second)*int64(rate)*100 + 1800) / 3600
+}
+
+type resolver struct {
+	workspaces     map[int]toggl.Workspace
+	workspaceUsers map[[2]int]int
+	projectUsers   map[[2]int]int
+	clients        map[int]int
+}
+
+func newResolver(opts Options) *resolver {
+	r := &resolver{
+		workspaces:     map[int]toggl.Workspace{},
+		workspaceUsers: map[[2]int]int{},
+		projectUsers:   map[[2]int]int{},
+		clients:        map[int]int{},
+	}
+	for _, w := range opts.Workspaces {
+		r.workspaces[w.ID] = w
+	}
+	for _, u := range opts.WorkspaceUsers {
+		r.workspaceUsers[[2]int{u.Wid, u.UID}] = u.Rate
+	}
+	for _, u := range opts.ProjectUsers {
+		r.projectUsers[[2]int{u.Pid, u.UID}] = u.Rate
+	}
+	for _, p := range opts.Projects {
+		r.clients[p.ID] = p.Cid
+	}
+	return r
+}
+
+// rate resolves the hourly rate of the entry. A zero rate is treated as unset.
+func (r *resolver) rate(te toggl.TimeEntry) (int, RateSource) {
+	if rate := r.projectUsers[[2]int{te.Pid, te.UID}]; te.Pid != 0 && rate != 0 {
+		return rate, RateProjectUser
+	}
+	if rate := r.workspaceUsers[[2]int{te.Wid, te.UID}]; rate != 0 {
+		return rate, RateWorkspaceUser
+	}
+	if rate := r.workspaces[te.Wid].DefaultHourlyRate; rate != 0 {
+		return rate, RateWorkspace
+	}
+	return 0, RateNone
+}
+
+// Names resolve the clients and projects of an invoice when rendering it.
+type Names struct {
+	Clients  map[int]string
+	Projects map[int]string
+}
+
+// WriteText renders a summary of the invoice, with the totals per client and
+// project followed by the totals per currency.
+func (inv *Invoice) WriteText(w io.Writer, names *Names) error {
+	if names == nil {
+		names = &Names{}
+	}
+	name := func(m map[int]string, id int, none string) string {
+		if id == 0 {
+			return none
+		}
+		if n, ok := m[id]; ok {
+			return n
+		}
+		return fmt.Sprintf("#%d", id)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	for _, c := range inv.Clients {
+		fmt.Fprintf(tw, "%s\t\t\t\n", name(names.Clients, c.Cid, "(no client)"))
+		for _, p := range c.Projects {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t\n", name(names.Projects, p.Pid, "(no project)"), formatDuration(p.Duration), formatAmount(p.Amount, p.Currency))
+		}
+		fmt.Fprintf(tw, "  Subtotal\t%s\t%s\t\n", formatDuration(c.Duration), formatAmount(c.Amount, c.Currency))
+	}
+
+	currencies := []string{}
+	for currency := range inv.Totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		fmt.Fprintf(tw, "Total\t\t%s\t\n", formatAmount(inv.Totals[currency], currency))
+	}
+
+	return tw.Flush()
+}
+
+func formatDuration(d time.Duration) string {
+	s := int64(d / time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+func formatAmount(amount int64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, amount/100, amount%100, currency)
+}
@@ -0,0 +1,107 @@
+package billing
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+func TestCalculate(t *testing.T) {
+	start := time.Date(2018, 4, 12, 9, 0, 0, 0, time.UTC)
+	opts := &Options{
+		Workspaces: []toggl.Workspace{
+			{ID: 1, DefaultHourlyRate: 50, DefaultCurrency: "EUR", Rounding: 1, RoundingMinutes: 15},
+			{ID: 2, DefaultCurrency: "USD"},
+		},
+		WorkspaceUsers: []toggl.WorkspaceUser{{Wid: 1, UID: 7, Rate: 80}},
+		ProjectUsers:   []toggl.User{{Pid: 10, UID: 7, Rate: 100}},
+		Projects:       []toggl.Project{{ID: 10, Cid: 100}, {ID: 20, Cid: 100}, {ID: 30}},
+		Now:            func() time.Time { return start.Add(2 * time.Hour) },
+	}
+	timeEntries := []toggl.TimeEntry{
+		// Project user rate, 50 minutes rounded up to an hour.
+		{ID: 1, Wid: 1, Pid: 10, UID: 7, Billable: true, Start: start, Duration: 50 * 60},
+		// Workspace user rate.
+		{ID: 2, Wid: 1, Pid: 20, UID: 7, Billable: true, Start: start, Duration: 30 * 60},
+		// Workspace rate, running for 2 hours.
+		{ID: 3, Wid: 1, Pid: 20, UID: 8, Billable: true, Start: start, Duration: -int(start.Unix())},
+		// No rate at all.
+		{ID: 4, Wid: 2, Pid: 30, UID: 7, Billable: true, Start: start, Duration: 3600},
+		// Not billable.
+		{ID: 5, Wid: 1, Pid: 10, UID: 7, Start: start, Duration: 3600},
+	}
+
+	invoice, err := Calculate(timeEntries, opts)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	type line struct {
+		ID       int
+		Duration time.Duration
+		Rate     int
+		Source   RateSource
+		Amount   int64
+	}
+	lines := []line{}
+	for _, l := range invoice.Lines {
+		lines = append(lines, line{l.Entry.ID, l.Duration, l.Rate, l.Source, l.Amount})
+	}
+	expectedLines := []line{
+		{1, time.Hour, 100, RateProjectUser, 10000},
+		{2, 30 * time.Minute, 80, RateWorkspaceUser, 4000},
+		{3, 2 * time.Hour, 50, RateWorkspace, 10000},
+		{4, time.Hour, 0, RateNone, 0},
+	}
+	if !reflect.DeepEqual(expectedLines, lines) {
+		t.Fatalf("Expected lines %+v, got %+v", expectedLines, lines)
+	}
+
+	expectedClients := []ClientTotal{
+		{Cid: 100, Currency: "EUR", Duration: 3*time.Hour + 30*time.Minute, Amount: 24000, Projects: []ProjectTotal{
+			{Pid: 10, Currency: "EUR", Duration: time.Hour, Amount: 10000},
+			{Pid: 20, Currency: "EUR", Duration: 2*time.Hour + 30*time.Minute, Amount: 14000},
+		}},
+		{Cid: 0, Currency: "USD", Duration: time.Hour, Amount: 0, Projects: []ProjectTotal{
+			{Pid: 30, Currency: "USD", Duration: time.Hour, Amount: 0},
+		}},
+	}
+	if !reflect.DeepEqual(expectedClients, invoice.Clients) {
+		t.Fatalf("Expected clients %+v, got %+v", expectedClients, invoice.Clients)
+	}
+	if !reflect.DeepEqual(map[string]int64{"EUR": 24000, "USD": 0}, invoice.Totals) {
+		t.Fatalf("Unexpected totals %v", invoice.Totals)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := invoice.WriteText(buf, &Names{Clients: map[int]string{100: "ACME"}, Projects: map[int]string{10: "Website"}}); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	for _, s := range []string{"ACME", "Website", "#20", "(no client)", "240.00 EUR", "0.00 USD", "3:30:00"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("Expected %q in the invoice:\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestCalculateUnknownWorkspace(t *testing.T) {
+	_, err := Calculate([]toggl.TimeEntry{{ID: 1, Wid: 1, Billable: true, Duration: 60}}, nil)
+	if err == nil {
+		t.Fatal("Expected an error for an unknown workspace")
+	}
+}
+
+func TestAmount(t *testing.T) {
+	if got := Amount(20*time.Minute, 100); got != 3333 {
+		t.Fatalf("Expected 3333, got %d", got)
+	}
+	if got := Amount(90*time.Second, 1); got != 3 {
+		t.Fatalf("Expected 3, got %d", got)
+	}
+}
@@ -0,0 +1,331 @@
+package toggl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedClient is a Client caching the workspaces, projects and tags lookups on disk.
+// The cached lookups are invalidated by the Create, Update, Patch and Delete calls
+// made through the CachedClient.
+type CachedClient struct {
+	*Client
+	path string
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	StoredAt time.Time       `json:"stored_at"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// CacheOption defines an option for a CachedClient
+type CacheOption func(*CachedClient)
+
+// CacheOptionPath sets the file the cache is stored in.
+func CacheOptionPath(path string) func(*CachedClient) {
+	return func(c *CachedClient) {
+		c.path = path
+	}
+}
+
+// CacheOptionTTL sets how long a cached lookup stays valid.
+func CacheOptionTTL(ttl time.Duration) func(*CachedClient) {
+	return func(c *CachedClient) {
+		c.ttl = ttl
+	}
+}
+
+// NewCachedClient builds a caching client around the given client.
+// The cache is stored in the user cache directory unless CacheOptionPath is given,
+// and lookups stay valid for an hour unless CacheOptionTTL is given.
+func NewCachedClient(client *Client, options ...CacheOption) (*CachedClient, error) {
+	c := &CachedClient{
+		Client: client,
+		ttl:    time.Hour,
+		now:    time.Now,
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	if c.path == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256([]byte(client.token))
+		c.path = filepath.Join(dir, "otms61-toggl", hex.EncodeToString(sum[:8])+".json")
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// GetWrokspaces will retrive the all workspace of the token onwner, from the cache when valid.
+func (c *CachedClient) GetWrokspaces(ctx context.Context) (*[]Workspace, error) {
+	response := &[]Workspace{}
+
+	err := c.cached("workspaces", response, func() (interface{}, error) {
+		return c.Client.GetWrokspaces(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetWorkspaceProjects will retrive the all active workspace projects, from the cache when valid.
+func (c *CachedClient) GetWorkspaceProjects(ctx context.Context, id int) (*[]Project, error) {
+	response := &[]Project{}
+
+	err := c.cached(projectsCacheKey(id), response, func() (interface{}, error) {
+		return c.Client.GetWorkspaceProjects(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetWorkspaceTags will retrive the all workspace tags, from the cache when valid.
+func (c *CachedClient) GetWorkspaceTags(ctx context.Context, id int) (*[]Tag, error) {
+	response := &[]Tag{}
+
+	err := c.cached(tagsCacheKey(id), response, func() (interface{}, error) {
+		return c.Client.GetWorkspaceTags(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// CreateProject creates a new project and invalidates the projects of its workspace.
+func (c *CachedClient) CreateProject(ctx context.Context, name string, workspaceID int, templateID int, isPrivate bool, clientID int) (*Project, error) {
+	project, err := c.Client.CreateProject(ctx, name, workspaceID, templateID, isPrivate, clientID)
+	c.invalidate(projectsCacheKey(workspaceID))
+	return project, err
+}
+
+// UpdateProject updates the project and invalidates the cached projects, as
+// the project may move from another workspace.
+func (c *CachedClient) UpdateProject(ctx context.Context, projectID int, name string, workspaceID int, templateID int, isPrivate bool, clientID int) (*Project, error) {
+	project, err := c.Client.UpdateProject(ctx, projectID, name, workspaceID, templateID, isPrivate, clientID)
+	c.invalidatePrefix(projectsCacheKey(0))
+	return project, err
+}
+
+// PatchProject updates the project and invalidates the cached projects.
+func (c *CachedClient) PatchProject(ctx context.Context, id int, update ProjectUpdate) (*Project, error) {
+	project, err := c.Client.PatchProject(ctx, id, update)
+	if err == nil {
+		c.invalidate(projectsCacheKey(project.Wid))
+	} else {
+		c.invalidatePrefix(projectsCacheKey(0))
+	}
+	return project, err
+}
+
+// DeleteProject deletes the project and invalidates the cached projects.
+func (c *CachedClient) DeleteProject(ctx context.Context, id int) error {
+	err := c.Client.DeleteProject(ctx, id)
+	c.invalidatePrefix(projectsCacheKey(0))
+	return err
+}
+
+// CreateTag creates a new tag and invalidates the tags of its workspace.
+func (c *CachedClient) CreateTag(ctx context.Context, name string, workspaceID int) (*Tag, error) {
+	tag, err := c.Client.CreateTag(ctx, name, workspaceID)
+	c.invalidate(tagsCacheKey(workspaceID))
+	return tag, err
+}
+
+// UpdateTag updates the tag and invalidates the cached tags.
+func (c *CachedClient) UpdateTag(ctx context.Context, id int, name string) (*Tag, error) {
+	tag, err := c.Client.UpdateTag(ctx, id, name)
+	c.invalidateTag(tag, err)
+	return tag, err
+}
+
+// PatchTag updates the tag and invalidates the cached tags.
+func (c *CachedClient) PatchTag(ctx context.Context, id int, update TagUpdate) (*Tag, error) {
+	tag, err := c.Client.PatchTag(ctx, id, update)
+	c.invalidateTag(tag, err)
+	return tag, err
+}
+
+// DeleteTag deletes the tag and invalidates the cached tags.
+func (c *CachedClient) DeleteTag(ctx context.Context, id int) error {
+	err := c.Client.DeleteTag(ctx, id)
+	c.invalidatePrefix(tagsCacheKey(0))
+	return err
+}
+
+// PatchWorkspace updates the workspace and invalidates the cached workspaces.
+func (c *CachedClient) PatchWorkspace(ctx context.Context, id int, update WorkspaceUpdate) (*Workspace, error) {
+	workspace, err := c.Client.PatchWorkspace(ctx, id, update)
+	c.invalidate("workspaces")
+	return workspace, err
+}
+
+// Refresh drops the whole cache and fetches the workspaces with their projects and tags again.
+func (c *CachedClient) Refresh(ctx context.Context) error {
+	c.mu.Lock()
+	c.entries = map[string]cacheEntry{}
+	c.mu.Unlock()
+
+	workspaces, err := c.GetWrokspaces(ctx)
+	if err != nil {
+		return err
+	}
+	for _, w := range *workspaces {
+		if _, err := c.GetWorkspaceProjects(ctx, w.ID); err != nil {
+			return err
+		}
+		if _, err := c.GetWorkspaceTags(ctx, w.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *CachedClient) invalidateTag(tag *Tag, err error) {
+	if err == nil && tag.Wid != 0 {
+		c.invalidate(tagsCacheKey(tag.Wid))
+		return
+	}
+	c.invalidatePrefix(tagsCacheKey(0))
+}
+
+// cached decodes the valid cache entry of key into v, or stores the result of fetch into
+// the cache before decoding it into v.
+func (c *CachedClient) cached(key string, v interface{}, fetch func() (interface{}, error)) error {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && c.now().Sub(entry.StoredAt) < c.ttl {
+		if err := json.Unmarshal(entry.Data, v); err == nil {
+			return nil
+		}
+	}
+
+	data, err := fetch()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{StoredAt: c.now(), Data: b}
+	c.saveLocked()
+	c.mu.Unlock()
+
+	return json.Unmarshal(b, v)
+}
+
+func (c *CachedClient) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; ok {
+		delete(c.entries, key)
+		c.saveLocked()
+	}
+}
+
+func (c *CachedClient) invalidatePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+	c.saveLocked()
+}
+
+func (c *CachedClient) load() error {
+	c.entries = map[string]cacheEntry{}
+
+	b, err := ioutil.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &c.entries); err != nil {
+		// A corrupted cache is dropped rather than failing every call.
+		c.Debugf("ignoring cache %s: %s", c.path, err)
+		c.entries = map[string]cacheEntry{}
+	}
+
+	return nil
+}
+
+// saveLocked writes the cache to disk. Failures are only logged, since the
+// cache is recreated from the server when missing.
+func (c *CachedClient) saveLocked() {
+	err := func() error {
+		b, err := json.Marshal(c.entries)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+			return err
+		}
+		tmp := c.path + ".tmp"
+		if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+			return err
+		}
+		return os.Rename(tmp, c.path)
+	}()
+	if err != nil {
+		c.Debugf("saving cache %s: %s", c.path, err)
+	}
+}
+
+// projectsCacheKey returns the cache key of the projects of a workspace.
+// The key of workspace 0 is the prefix of every projects key.
+func projectsCacheKey(workspaceID int) string {
+	if workspaceID == 0 {
+		return "projects/"
+	}
+	return fmt.Sprintf("projects/%d", workspaceID)
+}
+
+// tagsCacheKey returns the cache key of the tags of a workspace.
+// The key of workspace 0 is the prefix of every tags key.
+func tagsCacheKey(workspaceID int) string {
+	if workspaceID == 0 {
+		return "tags/"
+	}
+	return fmt.Sprintf("tags/%d", workspaceID)
+}
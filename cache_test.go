@@ -0,0 +1,191 @@
+package toggl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newCacheMockClient(requested map[string]int) *http.Client {
+	return newMockClient(func(req *http.Request) (*http.Response, error) {
+		key := req.Method + " " + req.URL.Path
+		requested[key]++
+
+		var v interface{}
+		switch key {
+		case "GET /api/v8/workspaces":
+			v = []Workspace{getTestWorkspace()}
+		case "GET /api/v8/workspaces/3278506/projects":
+			v = []Project{getTestProject()}
+		case "GET /api/v8/workspaces/3278506/tags":
+			v = []Tag{getTestTag()}
+		case "POST /api/v8/projects", "PUT /api/v8/projects/111358164":
+			v = struct {
+				Data Project `json:"data"`
+			}{
+				Data: getTestProject(),
+			}
+		case "DELETE /api/v8/tags/5740596":
+			v = []int{5740596}
+		default:
+			return nil, fmt.Errorf("Unexpected request %s", key)
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+	})
+}
+
+func TestCachedClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	requested := map[string]int{}
+	api := New("test", OptionHTTPClient(newCacheMockClient(requested)))
+
+	cached, err := NewCachedClient(api, CacheOptionPath(path))
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	for i := 0; i < 2; i++ {
+		workspaces, err := cached.GetWrokspaces(context.Background())
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if !reflect.DeepEqual([]Workspace{getTestWorkspace()}, *workspaces) {
+			t.Fatal(errors.New("Response is incorrect"))
+		}
+
+		tags, err := cached.GetWorkspaceTags(context.Background(), 3278506)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if !reflect.DeepEqual([]Tag{getTestTag()}, *tags) {
+			t.Fatal(errors.New("Response is incorrect"))
+		}
+	}
+	if requested["GET /api/v8/workspaces"] != 1 || requested["GET /api/v8/workspaces/3278506/tags"] != 1 {
+		t.Fatalf("Expected cached lookups, got requests %v", requested)
+	}
+
+	// A new client reads the cache stored on disk.
+	reopened, err := NewCachedClient(api, CacheOptionPath(path))
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if _, err := reopened.GetWrokspaces(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if requested["GET /api/v8/workspaces"] != 1 {
+		t.Fatalf("Expected the cache to be persisted, got requests %v", requested)
+	}
+
+	// Expired lookups are fetched again.
+	reopened.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := reopened.GetWrokspaces(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if requested["GET /api/v8/workspaces"] != 2 {
+		t.Fatalf("Expected the cache to expire, got requests %v", requested)
+	}
+}
+
+func TestCachedClientInvalidation(t *testing.T) {
+	requested := map[string]int{}
+	api := New("test", OptionHTTPClient(newCacheMockClient(requested)))
+
+	cached, err := NewCachedClient(api, CacheOptionPath(filepath.Join(t.TempDir(), "cache.json")))
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	if err := cached.Refresh(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	if _, err := cached.CreateProject(context.Background(), "test project", 3278506, 0, false, 0); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if err := cached.DeleteTag(context.Background(), 5740596); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := cached.GetWorkspaceProjects(context.Background(), 3278506); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if _, err := cached.GetWorkspaceTags(context.Background(), 3278506); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if _, err := cached.GetWrokspaces(context.Background()); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+	}
+
+	expected := map[string]int{
+		"GET /api/v8/workspaces":                  1,
+		"GET /api/v8/workspaces/3278506/projects": 2,
+		"GET /api/v8/workspaces/3278506/tags":     2,
+		"POST /api/v8/projects":                   1,
+		"DELETE /api/v8/tags/5740596":             1,
+	}
+	if !reflect.DeepEqual(expected, requested) {
+		t.Fatalf("Expected requests %v, got %v", expected, requested)
+	}
+}
+
+func TestCachedClientUpdateProjectMovesWorkspace(t *testing.T) {
+	requested := map[string]int{}
+	api := New("test", OptionHTTPClient(newCacheMockClient(requested)))
+
+	cached, err := NewCachedClient(api, CacheOptionPath(filepath.Join(t.TempDir(), "cache.json")))
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	if _, err := cached.GetWorkspaceProjects(context.Background(), 3278506); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	// The project moves from workspace 3278506 to workspace 2108335.
+	if _, err := cached.UpdateProject(context.Background(), 111358164, "test project", 2108335, 0, false, 0); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if _, err := cached.GetWorkspaceProjects(context.Background(), 3278506); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	if requested["GET /api/v8/workspaces/3278506/projects"] != 2 {
+		t.Fatalf("Expected the projects of the former workspace to be invalidated, got requests %v", requested)
+	}
+}
@@ -0,0 +1,70 @@
+// Command toggl-branch switches the toggl timer to a git branch.
+//
+// Usage:
+//
+//	toggl-branch [-dir path] <branch>
+//	toggl-branch [-dir path] -install
+//
+// The API token is read from TOGGL_API_TOKEN, and the workspace and projects
+// from the .toggl.json file at the root of the repository, which is required. With -install, a
+// post-checkout hook running toggl-branch is written to the repository.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/otms61/toggl"
+	"github.com/otms61/toggl/git"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the repository")
+	install := flag.Bool("install", false, "install the post-checkout hook")
+	flag.Parse()
+
+	if err := run(context.Background(), *dir, *install, flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "toggl-branch: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, dir string, install bool, args []string) error {
+	if install {
+		hook, err := git.InstallHook(ctx, dir, "toggl-branch")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("installed %s\n", hook)
+		return nil
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("usage: toggl-branch [-dir path] <branch>")
+	}
+	token := os.Getenv("TOGGL_API_TOKEN")
+	if token == "" {
+		return fmt.Errorf("TOGGL_API_TOKEN is not set")
+	}
+
+	root, err := git.RepositoryRoot(ctx, dir)
+	if err != nil {
+		return err
+	}
+	config, err := git.LoadBranchConfig(filepath.Join(root, git.BranchConfigFile))
+	if err != nil {
+		return err
+	}
+
+	running, err := git.SwitchBranch(ctx, toggl.New(token), config, args[0])
+	if err != nil {
+		return err
+	}
+	if running != nil {
+		fmt.Printf("tracking %s\n", running.Description)
+	}
+	return nil
+}
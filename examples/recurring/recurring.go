@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/otms61/toggl"
+	"github.com/otms61/toggl/recurring"
+)
+
+func main() {
+	api := toggl.New("API KEY")
+
+	config, err := recurring.LoadFile("recurring.json")
+	if err != nil {
+		fmt.Printf("Err %s\n", err)
+		os.Exit(1)
+	}
+
+	// Create this week's entries, printing them first with DryRun set.
+	now := time.Now()
+	start := now.AddDate(0, 0, -int(now.Weekday()))
+	report, err := recurring.Materialize(context.Background(), api, config, start, start.AddDate(0, 0, 6), &recurring.Options{DryRun: len(os.Args) > 1 && os.Args[1] == "-n"})
+	if report != nil {
+		for _, o := range report.Occurrences {
+			fmt.Println(o.Day.Format("2006-01-02"), o.Template, o.Skipped)
+		}
+	}
+	if err != nil {
+		fmt.Printf("Err %s\n", err)
+		os.Exit(1)
+	}
+}
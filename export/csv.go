@@ -0,0 +1,315 @@
+// Package export converts toggl time entries from and to file formats.
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+// Column is a column of a CSV export.
+type Column string
+
+// Columns supported by the CSV export and import.
+const (
+	ColumnDate        Column = "date"
+	ColumnStart       Column = "start"
+	ColumnStop        Column = "stop"
+	ColumnDuration    Column = "duration"
+	ColumnDescription Column = "description"
+	ColumnProject     Column = "project"
+	ColumnTags        Column = "tags"
+	ColumnBillable    Column = "billable"
+)
+
+// DefaultColumns are the columns written when none are configured.
+var DefaultColumns = []Column{
+	ColumnDate,
+	ColumnStart,
+	ColumnStop,
+	ColumnDuration,
+	ColumnDescription,
+	ColumnProject,
+	ColumnTags,
+	ColumnBillable,
+}
+
+const (
+	dateLayout  = "2006-01-02"
+	clockLayout = "15:04:05"
+	tagSep      = ";"
+)
+
+// CSVOptions configures the CSV export and import.
+type CSVOptions struct {
+	// Columns written or expected in the header. Defaults to DefaultColumns.
+	Columns []Column
+	// Location of the dates and times. Defaults to time.Local.
+	Location *time.Location
+	// Projects maps project IDs to names.
+	Projects map[int]string
+	// Now is used to measure running entries. Defaults to time.Now.
+	Now func() time.Time
+}
+
+func (o *CSVOptions) withDefaults() CSVOptions {
+	opts := CSVOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if len(opts.Columns) == 0 {
+		opts.Columns = DefaultColumns
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return opts
+}
+
+// WriteCSV writes the time entries as CSV, with a header row.
+// Durations are written as hours:minutes:seconds, and tags are separated by ";".
+func WriteCSV(w io.Writer, timeEntries []toggl.TimeEntry, options *CSVOptions) error {
+	opts := options.withDefaults()
+
+	cw := csv.NewWriter(w)
+	header := make([]string, len(opts.Columns))
+	for i, col := range opts.Columns {
+		header[i] = string(col)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	now := opts.Now()
+	for _, te := range timeEntries {
+		start := te.Start.In(opts.Location)
+		stop := te.EffectiveStop(now).In(opts.Location)
+
+		row := make([]string, len(opts.Columns))
+		for i, col := range opts.Columns {
+			switch col {
+			case ColumnDate:
+				row[i] = start.Format(dateLayout)
+			case ColumnStart:
+				row[i] = start.Format(clockLayout)
+			case ColumnStop:
+				if !te.IsRunning() {
+					row[i] = stop.Format(clockLayout)
+				}
+			case ColumnDuration:
+				row[i] = formatDuration(te.Elapsed(now))
+			case ColumnDescription:
+				row[i] = te.Description
+			case ColumnProject:
+				row[i] = opts.Projects[te.Pid]
+			case ColumnTags:
+				row[i] = strings.Join(te.Tags, tagSep)
+			case ColumnBillable:
+				row[i] = strconv.FormatBool(te.Billable)
+			default:
+				return fmt.Errorf("unknown column %q", col)
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatDuration(d time.Duration) string {
+	s := int64(d / time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return time.ParseDuration(s)
+	}
+
+	total := 0
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		total = total*60 + n
+	}
+	return time.Duration(total) * time.Second, nil
+}
+
+// TimeEntryCreator creates time entries. It is implemented by *toggl.Client.
+type TimeEntryCreator interface {
+	CreateTimeEntryWithOptions(ctx context.Context, opts toggl.TimeEntryOptions) (*toggl.TimeEntry, error)
+}
+
+// ImportOptions configures ImportCSV.
+type ImportOptions struct {
+	CSVOptions
+	// Wid is the workspace of the entries without project.
+	Wid int
+	// CreatedWith is sent as the created_with of the entries.
+	CreatedWith string
+	// DryRun parses and validates the rows without creating the entries.
+	DryRun bool
+}
+
+// RowResult is the outcome of a CSV row import.
+type RowResult struct {
+	// Row is the number of the row in the file, the header being row 0.
+	Row       int
+	Options   toggl.TimeEntryOptions
+	TimeEntry *toggl.TimeEntry
+	Err       error
+}
+
+// ImportReport lists the outcome of every imported row.
+type ImportReport struct {
+	Rows []RowResult
+}
+
+// Failed returns the rows that could not be imported.
+func (r *ImportReport) Failed() []RowResult {
+	failed := []RowResult{}
+	for _, row := range r.Rows {
+		if row.Err != nil {
+			failed = append(failed, row)
+		}
+	}
+	return failed
+}
+
+// ImportCSV reads time entries written by WriteCSV, or any CSV having a header
+// with the same column names, and creates them. Project names are resolved
+// through the Projects option. Invalid rows are reported and skipped, and
+// the error is only set when the CSV itself cannot be read.
+func ImportCSV(ctx context.Context, api TimeEntryCreator, r io.Reader, options *ImportOptions) (*ImportReport, error) {
+	opts := ImportOptions{}
+	if options != nil {
+		opts = *options
+	}
+	opts.CSVOptions = opts.CSVOptions.withDefaults()
+
+	projectIDs := map[string]int{}
+	for id, name := range opts.Projects {
+		projectIDs[strings.ToLower(name)] = id
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %s", err)
+	}
+	columns := map[Column]int{}
+	for i, name := range header {
+		columns[Column(strings.ToLower(strings.TrimSpace(name)))] = i
+	}
+	for _, col := range []Column{ColumnDate, ColumnStart} {
+		if _, ok := columns[col]; !ok {
+			return nil, fmt.Errorf("CSV header has no %q column", col)
+		}
+	}
+
+	report := &ImportReport{Rows: []RowResult{}}
+	for row := 1; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+
+		result := RowResult{Row: row}
+		result.Options, result.Err = parseRow(record, columns, projectIDs, opts)
+		if result.Err == nil && !opts.DryRun {
+			result.TimeEntry, result.Err = api.CreateTimeEntryWithOptions(ctx, result.Options)
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	return report, nil
+}
+
+func parseRow(record []string, columns map[Column]int, projectIDs map[string]int, opts ImportOptions) (toggl.TimeEntryOptions, error) {
+	field := func(col Column) string {
+		i, ok := columns[col]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	te := toggl.TimeEntryOptions{
+		Description: field(ColumnDescription),
+		Wid:         opts.Wid,
+		CreatedWith: opts.CreatedWith,
+	}
+
+	date, err := time.ParseInLocation(dateLayout, field(ColumnDate), opts.Location)
+	if err != nil {
+		return te, fmt.Errorf("invalid date %q", field(ColumnDate))
+	}
+	clock := func(col Column) (time.Time, error) {
+		t, err := time.Parse(clockLayout, field(col))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s %q", col, field(col))
+		}
+		return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, opts.Location), nil
+	}
+
+	if te.Start, err = clock(ColumnStart); err != nil {
+		return te, err
+	}
+	if field(ColumnStop) != "" {
+		if te.Stop, err = clock(ColumnStop); err != nil {
+			return te, err
+		}
+		// An entry stopping before its start spans midnight.
+		if te.Stop.Before(te.Start) {
+			te.Stop = te.Stop.AddDate(0, 0, 1)
+		}
+	}
+	if field(ColumnDuration) != "" {
+		d, err := parseDuration(field(ColumnDuration))
+		if err != nil {
+			return te, err
+		}
+		if te.Stop.IsZero() {
+			te.Duration = d
+		} else if d != te.Stop.Sub(te.Start) {
+			return te, fmt.Errorf("duration %s does not match the start and stop", field(ColumnDuration))
+		}
+	}
+
+	if name := field(ColumnProject); name != "" {
+		id, ok := projectIDs[strings.ToLower(name)]
+		if !ok {
+			return te, fmt.Errorf("unknown project %q", name)
+		}
+		te.Pid = id
+	}
+	if tags := field(ColumnTags); tags != "" {
+		te.Tags = toggl.NormalizeTags(strings.Split(tags, tagSep))
+	}
+	if billable := field(ColumnBillable); billable != "" {
+		if te.Billable, err = strconv.ParseBool(billable); err != nil {
+			return te, fmt.Errorf("invalid billable %q", billable)
+		}
+	}
+
+	return te, te.Validate()
+}
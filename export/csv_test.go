@@ -0,0 +1,133 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+type fakeCreator struct {
+	created []toggl.TimeEntryOptions
+}
+
+func (f *fakeCreator) CreateTimeEntryWithOptions(ctx context.Context, opts toggl.TimeEntryOptions) (*toggl.TimeEntry, error) {
+	f.created = append(f.created, opts)
+	return &toggl.TimeEntry{ID: len(f.created), Description: opts.Description}, nil
+}
+
+func getTestTimeEntries() []toggl.TimeEntry {
+	start := time.Date(2018, 4, 12, 7, 49, 0, 0, time.UTC)
+	return []toggl.TimeEntry{
+		{ID: 1, Pid: 10, Description: "toggl test", Billable: true, Start: start, Stop: start.Add(90 * time.Minute), Duration: 5400, Tags: []string{"fun", "dev"}},
+		{ID: 2, Description: "late, \"quoted\"", Start: start.Add(15 * time.Hour), Stop: start.Add(17 * time.Hour), Duration: 7200},
+		{ID: 3, Pid: 10, Description: "running", Start: start.Add(20 * time.Hour), Duration: -int(start.Add(20 * time.Hour).Unix())},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	now := time.Date(2018, 4, 13, 4, 0, 0, 0, time.UTC)
+	expected := `date,start,stop,duration,description,project,tags,billable
+2018-04-12,07:49:00,09:19:00,1:30:00,toggl test,test project,fun;dev,true
+2018-04-12,22:49:00,00:49:00,2:00:00,"late, ""quoted""",,,false
+2018-04-13,03:49:00,,0:11:00,running,test project,,false
+`
+
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, getTestTimeEntries(), &CSVOptions{
+		Location: time.UTC,
+		Projects: map[int]string{10: "test project"},
+		Now:      func() time.Time { return now },
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if buf.String() != expected {
+		t.Fatalf("Expected CSV\n%s\ngot\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteCSVColumns(t *testing.T) {
+	expected := "description,duration\ntoggl test,1:30:00\n"
+
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, getTestTimeEntries()[:1], &CSVOptions{
+		Columns: []Column{ColumnDescription, ColumnDuration},
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if buf.String() != expected {
+		t.Fatalf("Expected CSV\n%s\ngot\n%s", expected, buf.String())
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*3600)
+	input := `Date,Start,Stop,Duration,Description,Project,Tags,Billable
+2018-04-12,07:49:00,09:19:00,1:30:00,toggl test,Test Project,fun; dev;Fun,true
+2018-04-12,22:49:00,00:49:00,,late,,,
+2018-04-12,08:00:00,,45m,duration only,,,
+2018-04-12,08:00:00,09:00:00,,unknown project,other,,
+2018-13-12,08:00:00,09:00:00,,bad date,,,
+2018-04-12,08:00:00,,,no stop,,,
+`
+
+	opts := &ImportOptions{
+		CSVOptions: CSVOptions{
+			Location: tokyo,
+			Projects: map[int]string{10: "test project"},
+		},
+		Wid: 3278506,
+	}
+
+	creator := &fakeCreator{}
+	dryRun := *opts
+	dryRun.DryRun = true
+	report, err := ImportCSV(context.Background(), creator, strings.NewReader(input), &dryRun)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if len(creator.created) != 0 || len(report.Rows) != 6 || len(report.Failed()) != 3 {
+		t.Fatalf("Unexpected dry run report %+v", report)
+	}
+
+	report, err = ImportCSV(context.Background(), creator, strings.NewReader(input), opts)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	failed := []int{}
+	for _, row := range report.Failed() {
+		failed = append(failed, row.Row)
+	}
+	if !reflect.DeepEqual([]int{4, 5, 6}, failed) {
+		t.Fatalf("Expected rows 4, 5 and 6 to fail, got %v", failed)
+	}
+
+	start := time.Date(2018, 4, 12, 7, 49, 0, 0, tokyo)
+	expected := []toggl.TimeEntryOptions{
+		{Description: "toggl test", Wid: 3278506, Pid: 10, Tags: []string{"fun", "dev"}, Billable: true, Start: start, Stop: start.Add(90 * time.Minute)},
+		{Description: "late", Wid: 3278506, Start: start.Add(15 * time.Hour), Stop: start.Add(17 * time.Hour)},
+		{Description: "duration only", Wid: 3278506, Start: start.Add(11 * time.Minute), Duration: 45 * time.Minute},
+	}
+	if !reflect.DeepEqual(expected, creator.created) {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+}
+
+func TestImportCSVMissingColumns(t *testing.T) {
+	_, err := ImportCSV(context.Background(), &fakeCreator{}, strings.NewReader("description\nfoo\n"), nil)
+	if err == nil {
+		t.Fatal(errors.New("Expected an error for a header without date and start"))
+	}
+}
@@ -0,0 +1,140 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/otms61/toggl"
+)
+
+// EntryFilter selects the time entries written by WriteICS.
+type EntryFilter int
+
+// Filters supported by WriteICS.
+const (
+	AllEntries EntryFilter = iota
+	RunningEntries
+	StoppedEntries
+)
+
+const icsTimeLayout = "20060102T150405Z"
+
+// ICSOptions configures WriteICS.
+type ICSOptions struct {
+	// Projects maps project IDs to names, appended to the event summaries.
+	Projects map[int]string
+	// Name of the calendar.
+	Name string
+	// Filter selects the written entries. Defaults to all entries.
+	Filter EntryFilter
+	// Now ends the running entries and stamps the events. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// WriteICS writes the time entries as an RFC 5545 calendar, one event per entry.
+// Times are written in UTC, and running entries end now.
+func WriteICS(w io.Writer, timeEntries []toggl.TimeEntry, options *ICSOptions) error {
+	opts := ICSOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	now := opts.Now()
+
+	iw := &icsWriter{w: bufio.NewWriter(w)}
+	iw.line("BEGIN:VCALENDAR")
+	iw.line("VERSION:2.0")
+	iw.line("PRODID:-//otms61//toggl//EN")
+	iw.line("CALSCALE:GREGORIAN")
+	if opts.Name != "" {
+		iw.line("X-WR-CALNAME:" + escapeText(opts.Name))
+	}
+
+	for _, te := range timeEntries {
+		if opts.Filter == RunningEntries && !te.IsRunning() || opts.Filter == StoppedEntries && te.IsRunning() {
+			continue
+		}
+
+		uid := te.GUID
+		if uid == "" {
+			uid = strconv.Itoa(te.ID)
+		}
+		summary := te.Description
+		if summary == "" {
+			summary = "(no description)"
+		}
+		if project := opts.Projects[te.Pid]; project != "" {
+			summary += " (" + project + ")"
+		}
+
+		iw.line("BEGIN:VEVENT")
+		iw.line("UID:" + escapeText(uid) + "@toggl.com")
+		iw.line("DTSTAMP:" + now.UTC().Format(icsTimeLayout))
+		iw.line("DTSTART:" + te.Start.UTC().Format(icsTimeLayout))
+		iw.line("DTEND:" + te.EffectiveStop(now).UTC().Format(icsTimeLayout))
+		iw.line("SUMMARY:" + escapeText(summary))
+		if len(te.Tags) > 0 {
+			tags := make([]string, len(te.Tags))
+			for i, tag := range te.Tags {
+				tags[i] = escapeText(tag)
+			}
+			iw.line("CATEGORIES:" + strings.Join(tags, ","))
+		}
+		if !te.At.IsZero() {
+			iw.line("LAST-MODIFIED:" + te.At.UTC().Format(icsTimeLayout))
+		}
+		iw.line("END:VEVENT")
+	}
+
+	iw.line("END:VCALENDAR")
+	if iw.err != nil {
+		return iw.err
+	}
+	return iw.w.Flush()
+}
+
+// icsWriter writes content lines folded at 75 octets and ended by CRLF.
+type icsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (iw *icsWriter) line(s string) {
+	if iw.err != nil {
+		return
+	}
+
+	limit := 75
+	for len(s) > limit {
+		// Fold on a rune boundary so multi-byte characters are not split.
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if _, iw.err = fmt.Fprintf(iw.w, "%s\r\n ", s[:cut]); iw.err != nil {
+			return
+		}
+		s = s[cut:]
+		// Continuation lines start with a space, leaving 74 octets of content.
+		limit = 74
+	}
+	_, iw.err = fmt.Fprintf(iw.w, "%s\r\n", s)
+}
+
+// escapeText escapes a TEXT value as defined by RFC 5545 section 3.3.11.
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
@@ -0,0 +1,92 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/otms61/toggl"
+)
+
+func TestWriteICS(t *testing.T) {
+	now := time.Date(2018, 4, 13, 4, 0, 0, 0, time.UTC)
+	tokyo := time.FixedZone("JST", 9*3600)
+	start := time.Date(2018, 4, 12, 16, 49, 0, 0, tokyo)
+	timeEntries := []toggl.TimeEntry{
+		{ID: 1, GUID: "31424791f57b72c9cbbd80ed0f85790a", Pid: 10, Description: "review; fix, ship", Start: start, Stop: start.Add(30 * time.Minute), Duration: 1800, Tags: []string{"fun", "a,b"}},
+		{ID: 2, Description: "running", Start: now.Add(-time.Hour), Duration: -int(now.Add(-time.Hour).Unix())},
+	}
+
+	expected := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//otms61//toggl//EN",
+		"CALSCALE:GREGORIAN",
+		"BEGIN:VEVENT",
+		"UID:31424791f57b72c9cbbd80ed0f85790a@toggl.com",
+		"DTSTAMP:20180413T040000Z",
+		"DTSTART:20180412T074900Z",
+		"DTEND:20180412T081900Z",
+		`SUMMARY:review\; fix\, ship (test project)`,
+		`CATEGORIES:fun,a\,b`,
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:2@toggl.com",
+		"DTSTAMP:20180413T040000Z",
+		"DTSTART:20180413T030000Z",
+		"DTEND:20180413T040000Z",
+		"SUMMARY:running",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	var buf bytes.Buffer
+	err := WriteICS(&buf, timeEntries, &ICSOptions{
+		Projects: map[int]string{10: "test project"},
+		Now:      func() time.Time { return now },
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if buf.String() != expected {
+		t.Fatalf("Expected calendar\n%s\ngot\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	err = WriteICS(&buf, timeEntries, &ICSOptions{Filter: StoppedEntries, Now: func() time.Time { return now }})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if strings.Count(buf.String(), "BEGIN:VEVENT") != 1 || strings.Contains(buf.String(), "running") {
+		t.Fatalf("Expected only the stopped entry, got\n%s", buf.String())
+	}
+}
+
+func TestWriteICSFolding(t *testing.T) {
+	description := strings.Repeat("日本語", 20) + "\nsecond line"
+	timeEntries := []toggl.TimeEntry{{ID: 1, Description: description, Duration: 60}}
+
+	var buf bytes.Buffer
+	if err := WriteICS(&buf, timeEntries, nil); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	unfolded := strings.Replace(buf.String(), "\r\n ", "", -1)
+	if !strings.Contains(unfolded, `SUMMARY:`+strings.Repeat("日本語", 20)+`\nsecond line`) {
+		t.Fatalf("Unexpected summary in\n%s", buf.String())
+	}
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > 75 {
+			t.Fatalf("Line longer than 75 octets: %q", line)
+		}
+		if !utf8.ValidString(line) {
+			t.Fatalf("Line folded inside a character: %q", line)
+		}
+	}
+}
@@ -0,0 +1,580 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+// CalendarEvent is an occurrence of a calendar event.
+type CalendarEvent struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time
+	// AllDay is set for events scheduled by date only.
+	AllDay bool
+	// Declined is set for cancelled events, and events the attendee declined.
+	Declined bool
+}
+
+// ICSRule maps the calendar events whose summary matches Pattern to time entries.
+type ICSRule struct {
+	Pattern  *regexp.Regexp
+	Pid      int
+	Tags     []string
+	Billable bool
+	// Description replaces the summary of the event when set.
+	Description string
+}
+
+// ICSImportOptions configures ParseICS and ImportICS.
+type ICSImportOptions struct {
+	// Location of the floating times and unknown time zones. Defaults to time.Local.
+	Location *time.Location
+	// Attendee is the email address whose declined invitations are skipped.
+	Attendee string
+	// Rules are tried in order, the first matching rule maps the event.
+	Rules []ICSRule
+	// SkipUnmatched skips the events matching no rule instead of importing them without project.
+	SkipUnmatched bool
+	// Wid is the workspace of the entries without project.
+	Wid         int
+	CreatedWith string
+	// DryRun plans the import without creating the entries.
+	DryRun bool
+}
+
+// TimeEntryAPI reads and creates time entries. It is implemented by *toggl.Client.
+type TimeEntryAPI interface {
+	TimeEntryCreator
+	GetTimeEntries(ctx context.Context, start, end time.Time) (*[]toggl.TimeEntry, error)
+}
+
+// ImportedEvent is a calendar event imported, or planned to be imported in a dry run.
+type ImportedEvent struct {
+	Event     CalendarEvent
+	Options   toggl.TimeEntryOptions
+	TimeEntry *toggl.TimeEntry
+	Err       error
+}
+
+// SkippedEvent is a calendar event not imported.
+type SkippedEvent struct {
+	Event  CalendarEvent
+	Reason string
+}
+
+// ICSImportReport lists the outcome of every calendar event of an import.
+type ICSImportReport struct {
+	Imported []ImportedEvent
+	Skipped  []SkippedEvent
+}
+
+// ImportICS creates time entries from the calendar events occurring between start and end.
+// All-day, cancelled and declined events are skipped, as well as events already
+// having a time entry with the same start and description.
+func ImportICS(ctx context.Context, api TimeEntryAPI, r io.Reader, start, end time.Time, options *ICSImportOptions) (*ICSImportReport, error) {
+	opts := icsImportDefaults(options)
+
+	events, err := ParseICS(r, start, end, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := api.GetTimeEntries(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	tracked := map[string]bool{}
+	for _, te := range *existing {
+		tracked[trackedKey(te.Start, te.Description)] = true
+	}
+
+	report := &ICSImportReport{
+		Imported: []ImportedEvent{},
+		Skipped:  []SkippedEvent{},
+	}
+	for _, event := range events {
+		switch {
+		case event.AllDay:
+			report.Skipped = append(report.Skipped, SkippedEvent{Event: event, Reason: "all-day event"})
+			continue
+		case event.Declined:
+			report.Skipped = append(report.Skipped, SkippedEvent{Event: event, Reason: "declined event"})
+			continue
+		}
+
+		te, ok := mapEvent(event, opts)
+		if !ok {
+			report.Skipped = append(report.Skipped, SkippedEvent{Event: event, Reason: "no matching rule"})
+			continue
+		}
+		key := trackedKey(te.Start, te.Description)
+		if tracked[key] {
+			report.Skipped = append(report.Skipped, SkippedEvent{Event: event, Reason: "already tracked"})
+			continue
+		}
+		tracked[key] = true
+
+		imported := ImportedEvent{Event: event, Options: te, Err: te.Validate()}
+		if imported.Err == nil && !opts.DryRun {
+			imported.TimeEntry, imported.Err = api.CreateTimeEntryWithOptions(ctx, te)
+		}
+		report.Imported = append(report.Imported, imported)
+	}
+
+	return report, nil
+}
+
+func icsImportDefaults(options *ICSImportOptions) ICSImportOptions {
+	opts := ICSImportOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	return opts
+}
+
+func mapEvent(event CalendarEvent, opts ICSImportOptions) (toggl.TimeEntryOptions, bool) {
+	te := toggl.TimeEntryOptions{
+		Description: event.Summary,
+		Wid:         opts.Wid,
+		Start:       event.Start,
+		Stop:        event.End,
+		CreatedWith: opts.CreatedWith,
+	}
+
+	for _, rule := range opts.Rules {
+		if rule.Pattern == nil || !rule.Pattern.MatchString(event.Summary) {
+			continue
+		}
+		te.Pid = rule.Pid
+		te.Tags = rule.Tags
+		te.Billable = rule.Billable
+		if rule.Description != "" {
+			te.Description = rule.Description
+		}
+		return te, true
+	}
+
+	return te, !opts.SkipUnmatched
+}
+
+func trackedKey(start time.Time, description string) string {
+	return start.UTC().Truncate(time.Minute).Format(time.RFC3339) + " " + strings.ToLower(strings.TrimSpace(description))
+}
+
+// icsProperty is a content line of a calendar.
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// icsEvent is a VEVENT before its recurrences are expanded.
+type icsEvent struct {
+	uid          string
+	summary      string
+	start        time.Time
+	end          time.Time
+	duration     time.Duration
+	allDay       bool
+	declined     bool
+	rrule        string
+	exdates      []time.Time
+	recurrenceID time.Time
+}
+
+// ParseICS reads the calendar events occurring between start and end,
+// expanding the recurring events. The events are sorted by start.
+func ParseICS(r io.Reader, start, end time.Time, options *ICSImportOptions) ([]CalendarEvent, error) {
+	opts := icsImportDefaults(options)
+
+	props, err := readICSProperties(r)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []icsEvent{}
+	var current *icsEvent
+	depth := 0
+	for _, p := range props {
+		switch {
+		case p.name == "BEGIN" && p.value == "VEVENT":
+			current = &icsEvent{}
+			depth = 0
+		case current == nil:
+		case p.name == "BEGIN":
+			// Nested components such as VALARM are ignored.
+			depth++
+		case p.name == "END" && p.value == "VEVENT":
+			if current.end.IsZero() {
+				current.end = current.start.Add(current.duration)
+				if current.allDay && current.duration == 0 {
+					current.end = current.start.AddDate(0, 0, 1)
+				}
+			}
+			events = append(events, *current)
+			current = nil
+		case p.name == "END":
+			depth--
+		case depth > 0:
+		default:
+			if err := current.set(p, opts); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Occurrences moved by a RECURRENCE-ID event replace the generated ones.
+	overridden := map[string]bool{}
+	for _, e := range events {
+		if !e.recurrenceID.IsZero() {
+			overridden[e.uid+e.recurrenceID.UTC().Format(icsTimeLayout)] = true
+		}
+	}
+
+	result := []CalendarEvent{}
+	for _, e := range events {
+		starts := []time.Time{e.start}
+		if e.rrule != "" && e.recurrenceID.IsZero() {
+			if starts, err = expandRRule(e.rrule, e.start, end, e.exdates); err != nil {
+				return nil, fmt.Errorf("event %s: %s", e.uid, err)
+			}
+		}
+
+		length := e.end.Sub(e.start)
+		for _, s := range starts {
+			if e.recurrenceID.IsZero() && overridden[e.uid+s.UTC().Format(icsTimeLayout)] {
+				continue
+			}
+			occurrence := CalendarEvent{
+				UID:      e.uid,
+				Summary:  e.summary,
+				Start:    s,
+				End:      s.Add(length),
+				AllDay:   e.allDay,
+				Declined: e.declined,
+			}
+			if occurrence.End.After(start) && occurrence.Start.Before(end) {
+				result = append(result, occurrence)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+
+	return result, nil
+}
+
+func (e *icsEvent) set(p icsProperty, opts ICSImportOptions) error {
+	var err error
+	switch p.name {
+	case "UID":
+		e.uid = p.value
+	case "SUMMARY":
+		e.summary = unescapeText(p.value)
+	case "DTSTART":
+		e.start, e.allDay, err = parseICSTime(p, opts.Location)
+	case "DTEND":
+		e.end, _, err = parseICSTime(p, opts.Location)
+	case "DURATION":
+		e.duration, err = parseICSDuration(p.value)
+	case "RRULE":
+		e.rrule = p.value
+	case "EXDATE":
+		for _, v := range strings.Split(p.value, ",") {
+			t, _, err := parseICSTime(icsProperty{params: p.params, value: v}, opts.Location)
+			if err != nil {
+				return err
+			}
+			e.exdates = append(e.exdates, t)
+		}
+	case "RECURRENCE-ID":
+		e.recurrenceID, _, err = parseICSTime(p, opts.Location)
+	case "STATUS":
+		if strings.EqualFold(p.value, "CANCELLED") {
+			e.declined = true
+		}
+	case "ATTENDEE":
+		email := strings.TrimPrefix(strings.ToLower(p.value), "mailto:")
+		if opts.Attendee != "" && email == strings.ToLower(opts.Attendee) && strings.EqualFold(p.params["PARTSTAT"], "DECLINED") {
+			e.declined = true
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %s", p.name, p.value, err)
+	}
+	return nil
+}
+
+// readICSProperties unfolds the content lines of a calendar and parses them.
+func readICSProperties(r io.Reader) ([]icsProperty, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || lines[0] != "BEGIN:VCALENDAR" {
+		return nil, errors.New("not an iCalendar file")
+	}
+
+	props := make([]icsProperty, 0, len(lines))
+	for _, line := range lines {
+		p, err := parseICSProperty(line)
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, p)
+	}
+	return props, nil
+}
+
+func parseICSProperty(line string) (icsProperty, error) {
+	// The value starts at the first colon outside of a quoted parameter value.
+	quoted := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return icsProperty{}, fmt.Errorf("invalid content line %q", line)
+	}
+
+	p := icsProperty{params: map[string]string{}, value: line[colon+1:]}
+	parts := strings.Split(line[:colon], ";")
+	p.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) == 2 {
+			p.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return p, nil
+}
+
+func parseICSTime(p icsProperty, loc *time.Location) (time.Time, bool, error) {
+	value := strings.TrimSpace(p.value)
+	if p.params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icsTimeLayout, value)
+		return t, false, err
+	}
+
+	if tzid := p.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+var icsDurationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+func parseICSDuration(s string) (time.Duration, error) {
+	m := icsDurationPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, errors.New("invalid duration")
+	}
+
+	var d time.Duration
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(n) * unit
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+func unescapeText(s string) string {
+	return strings.NewReplacer(
+		`\\`, `\`,
+		`\;`, ";",
+		`\,`, ",",
+		`\n`, "\n",
+		`\N`, "\n",
+	).Replace(s)
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// maxOccurrences bounds the expansion of rules without COUNT nor UNTIL.
+const maxOccurrences = 10000
+
+// expandRRule returns the starts of the occurrences of a recurring event until end.
+// It supports the DAILY, WEEKLY, MONTHLY and YEARLY frequencies with INTERVAL,
+// COUNT, UNTIL, and BYDAY for weekly rules.
+func expandRRule(rule string, dtstart, end time.Time, exdates []time.Time) ([]time.Time, error) {
+	freq := ""
+	interval := 1
+	count := 0
+	until := time.Time{}
+	byday := []time.Weekday{}
+
+	for _, part := range strings.Split(rule, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		var err error
+		switch strings.ToUpper(kv[0]) {
+		case "FREQ":
+			freq = strings.ToUpper(kv[1])
+		case "INTERVAL":
+			interval, err = strconv.Atoi(kv[1])
+			if err == nil && interval < 1 {
+				err = errors.New("interval must be positive")
+			}
+		case "COUNT":
+			count, err = strconv.Atoi(kv[1])
+		case "UNTIL":
+			until, _, err = parseICSTime(icsProperty{value: kv[1]}, dtstart.Location())
+		case "BYDAY":
+			for _, day := range strings.Split(kv[1], ",") {
+				wd, ok := icsWeekdays[strings.ToUpper(day)]
+				if !ok {
+					return nil, fmt.Errorf("unsupported BYDAY %q", day)
+				}
+				byday = append(byday, wd)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid RRULE %s: %s", part, err)
+		}
+	}
+
+	excluded := map[int64]bool{}
+	for _, t := range exdates {
+		excluded[t.Unix()] = true
+	}
+
+	// Occurrences are computed on the wall clock of the event, so that they
+	// keep their local time across daylight saving changes.
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location())
+	}
+
+	starts := []time.Time{}
+	n := 0
+	emit := func(t time.Time) bool {
+		if t.Before(dtstart) {
+			return true
+		}
+		if !until.IsZero() && t.After(until) || !t.Before(end) || count > 0 && n >= count || n >= maxOccurrences {
+			return false
+		}
+		n++
+		if !excluded[t.Unix()] {
+			starts = append(starts, t)
+		}
+		return true
+	}
+
+	y, m, d := dtstart.Date()
+	switch freq {
+	case "DAILY":
+		for i := 0; emit(at(y, m, d+i*interval)); i++ {
+		}
+	case "WEEKLY":
+		if len(byday) == 0 {
+			byday = []time.Weekday{dtstart.Weekday()}
+		}
+		// Weeks start on monday.
+		offsets := []int{}
+		for _, wd := range byday {
+			offsets = append(offsets, (int(wd)+6)%7)
+		}
+		sort.Ints(offsets)
+		monday := d - (int(dtstart.Weekday())+6)%7
+		for week := 0; ; week++ {
+			more := true
+			for _, offset := range offsets {
+				if more = emit(at(y, m, monday+week*7*interval+offset)); !more {
+					break
+				}
+			}
+			if !more {
+				break
+			}
+		}
+	case "MONTHLY":
+		for i := 0; ; i++ {
+			t := at(y, m+time.Month(i*interval), d)
+			// Months without the day of the event are skipped.
+			if t.Day() != d {
+				if i > maxOccurrences {
+					break
+				}
+				continue
+			}
+			if !emit(t) {
+				break
+			}
+		}
+	case "YEARLY":
+		for i := 0; ; i++ {
+			t := at(y+i*interval, m, d)
+			if t.Day() != d {
+				continue
+			}
+			if !emit(t) {
+				break
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported recurrence frequency %q", freq)
+	}
+
+	return starts, nil
+}
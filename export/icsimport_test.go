@@ -0,0 +1,215 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+type fakeTimeEntryAPI struct {
+	fakeCreator
+	existing []toggl.TimeEntry
+}
+
+func (f *fakeTimeEntryAPI) GetTimeEntries(ctx context.Context, start, end time.Time) (*[]toggl.TimeEntry, error) {
+	return &f.existing, nil
+}
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//test//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"DTSTART;TZID=America/New_York:20180305T093000\r\n" +
+	"DURATION:PT15M\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=6\r\n" +
+	"EXDATE;TZID=America/New_York:20180309T093000\r\n" +
+	"SUMMARY:Daily standup\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"SUMMARY:alarm\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"RECURRENCE-ID;TZID=America/New_York:20180312T093000\r\n" +
+	"DTSTART;TZID=America/New_York:20180312T100000\r\n" +
+	"DTEND;TZID=America/New_York:20180312T101500\r\n" +
+	"SUMMARY:Daily standup (moved)\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:review\r\n" +
+	"DTSTART:20180306T150000Z\r\n" +
+	"DTEND:20180306T160000Z\r\n" +
+	"SUMMARY:Design review\\, billing\r\n" +
+	"  project\r\n" +
+	"ATTENDEE;PARTSTAT=ACCEPTED:mailto:me@example.com\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:declined\r\n" +
+	"DTSTART:20180307T150000Z\r\n" +
+	"DTEND:20180307T160000Z\r\n" +
+	"SUMMARY:All hands\r\n" +
+	"ATTENDEE;CN=\"Me: myself\";PARTSTAT=DECLINED:mailto:ME@example.com\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:cancelled\r\n" +
+	"DTSTART:20180308T150000Z\r\n" +
+	"DTEND:20180308T160000Z\r\n" +
+	"SUMMARY:Retro\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday\r\n" +
+	"DTSTART;VALUE=DATE:20180309\r\n" +
+	"SUMMARY:Holiday\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICS(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	start := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	events, err := ParseICS(strings.NewReader(testCalendar), start, start.AddDate(0, 1, 0), &ICSImportOptions{
+		Location: time.UTC,
+		Attendee: "me@example.com",
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	summary := []string{}
+	for _, e := range events {
+		summary = append(summary, e.Start.UTC().Format("01-02 15:04")+" "+e.End.Sub(e.Start).String()+" "+e.Summary)
+	}
+	expected := []string{
+		"03-05 14:30 15m0s Daily standup",
+		"03-06 15:00 1h0m0s Design review, billing project",
+		"03-07 14:30 15m0s Daily standup",
+		"03-07 15:00 1h0m0s All hands",
+		"03-08 15:00 1h0m0s Retro",
+		"03-09 00:00 24h0m0s Holiday",
+		// Daylight saving time starts on 03-11, the standup keeps its local time.
+		"03-12 14:00 15m0s Daily standup (moved)",
+		"03-14 13:30 15m0s Daily standup",
+		"03-16 13:30 15m0s Daily standup",
+	}
+	if !reflect.DeepEqual(expected, summary) {
+		t.Fatalf("Expected events\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(summary, "\n"))
+	}
+	if !events[0].Start.Equal(time.Date(2018, 3, 5, 9, 30, 0, 0, newYork)) {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+	if !events[3].Declined || !events[4].Declined || !events[5].AllDay || events[1].Declined {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+}
+
+func TestExpandRRule(t *testing.T) {
+	dtstart := time.Date(2018, 1, 31, 10, 0, 0, 0, time.UTC)
+	end := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		rule     string
+		expected []string
+	}{
+		{"FREQ=DAILY;INTERVAL=2;COUNT=3", []string{"01-31", "02-02", "02-04"}},
+		{"FREQ=MONTHLY;UNTIL=20180601T000000Z", []string{"01-31", "03-31", "05-31"}},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,WE;COUNT=4", []string{"01-31", "02-13", "02-14", "02-27"}},
+		{"FREQ=YEARLY", []string{"01-31"}},
+	}
+
+	for _, test := range tests {
+		starts, err := expandRRule(test.rule, dtstart, end, nil)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.rule, err)
+			continue
+		}
+		got := []string{}
+		for _, s := range starts {
+			got = append(got, s.Format("01-02"))
+		}
+		if !reflect.DeepEqual(test.expected, got) {
+			t.Errorf("%s: expected %v, got %v", test.rule, test.expected, got)
+		}
+	}
+
+	if _, err := expandRRule("FREQ=HOURLY", dtstart, end, nil); err == nil {
+		t.Fatal(errors.New("Expected an error for an unsupported frequency"))
+	}
+}
+
+func TestImportICS(t *testing.T) {
+	if _, err := time.LoadLocation("America/New_York"); err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	start := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	api := &fakeTimeEntryAPI{
+		existing: []toggl.TimeEntry{
+			{ID: 1, Description: "standup", Start: time.Date(2018, 3, 14, 13, 30, 0, 0, time.UTC)},
+		},
+	}
+	opts := &ICSImportOptions{
+		Location: time.UTC,
+		Attendee: "me@example.com",
+		Rules: []ICSRule{
+			{Pattern: regexp.MustCompile(`(?i)standup`), Pid: 10, Tags: []string{"meeting"}, Description: "standup"},
+			{Pattern: regexp.MustCompile(`(?i)review`), Pid: 20, Billable: true},
+		},
+		SkipUnmatched: true,
+		Wid:           3278506,
+	}
+
+	preview := *opts
+	preview.DryRun = true
+	report, err := ImportICS(context.Background(), api, strings.NewReader(testCalendar), start, start.AddDate(0, 1, 0), &preview)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if len(api.created) != 0 || len(report.Imported) != 5 {
+		t.Fatalf("Unexpected preview %+v", report)
+	}
+
+	report, err = ImportICS(context.Background(), api, strings.NewReader(testCalendar), start, start.AddDate(0, 1, 0), opts)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	reasons := []string{}
+	for _, s := range report.Skipped {
+		reasons = append(reasons, s.Reason)
+	}
+	expectedReasons := []string{"declined event", "declined event", "all-day event", "already tracked"}
+	if !reflect.DeepEqual(expectedReasons, reasons) {
+		t.Fatalf("Expected skip reasons %v, got %v", expectedReasons, reasons)
+	}
+	if len(api.created) != 5 {
+		t.Fatalf("Expected 5 entries, got %d", len(api.created))
+	}
+
+	review := api.created[1]
+	expected := toggl.TimeEntryOptions{
+		Description: "Design review, billing project",
+		Wid:         3278506,
+		Pid:         20,
+		Billable:    true,
+		Start:       time.Date(2018, 3, 6, 15, 0, 0, 0, time.UTC),
+		Stop:        time.Date(2018, 3, 6, 16, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(expected, review) {
+		t.Fatalf("Expected %+v, got %+v", expected, review)
+	}
+}
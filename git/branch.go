@@ -0,0 +1,223 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/otms61/toggl"
+)
+
+// BranchConfigFile is the name of the per-repository branch timer configuration.
+const BranchConfigFile = ".toggl.json"
+
+// hookMarker identifies the hooks written by InstallHook.
+const hookMarker = "# generated by toggl-branch"
+
+// issueKeyPattern matches issue keys like ABC-123.
+var issueKeyPattern = regexp.MustCompile(`[A-Z][A-Z0-9]+-[0-9]+`)
+
+// lowerIssueKeyPattern matches lower case issue keys like abc-123.
+var lowerIssueKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9]+-[0-9]+$`)
+
+// IssueKey returns the issue key found in the branch name, upper cased, or "" without one.
+// Lower case keys are only recognized as a whole path segment, like feature/abc-123,
+// so that names like python-3-upgrade are not taken for keys.
+func IssueKey(branch string) string {
+	if key := issueKeyPattern.FindString(branch); key != "" {
+		return key
+	}
+	for _, segment := range strings.Split(branch, "/") {
+		if lowerIssueKeyPattern.MatchString(segment) {
+			return strings.ToUpper(segment)
+		}
+	}
+	return ""
+}
+
+// BranchConfig maps the branches of a repository to the time entries tracked on them.
+type BranchConfig struct {
+	// Wid and Pid are the workspace and the default project of the time entries.
+	Wid int `json:"wid"`
+	Pid int `json:"pid"`
+	// Tags are added to the time entries.
+	Tags []string `json:"tags"`
+	// Projects maps issue key prefixes, like "ABC", or branch patterns, like
+	// "release/*", to projects. Issue key prefixes are looked up first, then
+	// the patterns in lexical order.
+	Projects map[string]int `json:"projects"`
+	// Ignore lists the branch patterns nothing is tracked on. Defaults to main and master.
+	Ignore []string `json:"ignore"`
+	// CreatedWith is sent as the created_with of the time entries.
+	CreatedWith string `json:"created_with"`
+}
+
+// LoadBranchConfig reads and validates the configuration stored at path.
+func LoadBranchConfig(path string) (*BranchConfig, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &BranchConfig{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("reading %s: %s", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("reading %s: %s", path, err)
+	}
+	return c, nil
+}
+
+// Validate checks that the configuration has a workspace or a default project,
+// which every time entry needs.
+func (c *BranchConfig) Validate() error {
+	if c.Wid == 0 && c.Pid == 0 {
+		return errors.New("branch config needs a wid or pid")
+	}
+	return nil
+}
+
+// Resolve returns the time entry tracked on the branch. Its description is the
+// issue key of the branch, or the branch name without issue key. It returns
+// false for ignored branches, and an error for an invalid configuration.
+func (c *BranchConfig) Resolve(branch string) (toggl.TimeEntryOptions, bool, error) {
+	if err := c.Validate(); err != nil {
+		return toggl.TimeEntryOptions{}, false, err
+	}
+
+	ignore := c.Ignore
+	if ignore == nil {
+		ignore = []string{"main", "master"}
+	}
+	for _, pattern := range ignore {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return toggl.TimeEntryOptions{}, false, nil
+		}
+	}
+
+	opts := toggl.TimeEntryOptions{
+		Description: branch,
+		Wid:         c.Wid,
+		Pid:         c.Pid,
+		Tags:        c.Tags,
+		CreatedWith: c.CreatedWith,
+	}
+
+	key := IssueKey(branch)
+	if key != "" {
+		opts.Description = key
+		if pid, ok := c.Projects[key[:strings.Index(key, "-")]]; ok {
+			opts.Pid = pid
+			return opts, true, nil
+		}
+	}
+	patterns := []string{}
+	for pattern := range c.Projects {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, branch); ok {
+			opts.Pid = c.Projects[pattern]
+			break
+		}
+	}
+
+	return opts, true, nil
+}
+
+// TimerAPI is the part of the client used to switch the timer.
+type TimerAPI interface {
+	GetRunningTimeEntry(ctx context.Context) (*toggl.TimeEntry, error)
+	StopTimeEntry(ctx context.Context, id int) error
+	StartTimeEntryWithOptions(ctx context.Context, opts toggl.TimeEntryOptions) (*toggl.TimeEntry, error)
+}
+
+// SwitchBranch stops the running time entry and starts the one of the branch.
+// The running time entry is kept when it already is the one of the branch,
+// and nothing is started on ignored branches. It returns the running time
+// entry, nil when nothing runs. The running time entry is not stopped when
+// the configuration is invalid.
+func SwitchBranch(ctx context.Context, api TimerAPI, config *BranchConfig, branch string) (*toggl.TimeEntry, error) {
+	opts, track, err := config.Resolve(branch)
+	if err != nil {
+		return nil, err
+	}
+
+	running, err := api.GetRunningTimeEntry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if running != nil {
+		if track && running.Description == opts.Description && running.Pid == opts.Pid {
+			return running, nil
+		}
+		if err := api.StopTimeEntry(ctx, running.ID); err != nil {
+			return nil, err
+		}
+	}
+	if !track {
+		return nil, nil
+	}
+
+	return api.StartTimeEntryWithOptions(ctx, opts)
+}
+
+// HookScript returns a post-checkout hook running the command with the name
+// of the checked out branch. Detached checkouts and file checkouts are ignored.
+func HookScript(command string) string {
+	return strings.Join([]string{
+		"#!/bin/sh",
+		hookMarker,
+		"# Switches the toggl timer to the checked out branch.",
+		`[ "$3" = "1" ] || exit 0`,
+		`branch=$(git rev-parse --abbrev-ref HEAD)`,
+		`[ "$branch" = "HEAD" ] && exit 0`,
+		command + ` "$branch"`,
+		"",
+	}, "\n")
+}
+
+// InstallHook writes the post-checkout hook of the repository in dir. A hook
+// not written by InstallHook is not overwritten.
+func InstallHook(ctx context.Context, dir string, command string) (string, error) {
+	out, err := run(ctx, dir, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	hooks := strings.TrimSpace(out)
+	if !filepath.IsAbs(hooks) {
+		hooks = filepath.Join(dir, hooks)
+	}
+	hook := filepath.Join(hooks, "post-checkout")
+
+	b, err := ioutil.ReadFile(hook)
+	if err == nil && !strings.Contains(string(b), hookMarker) {
+		return "", fmt.Errorf("%s already exists", hook)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	if err := os.MkdirAll(hooks, 0755); err != nil {
+		return "", err
+	}
+	return hook, ioutil.WriteFile(hook, []byte(HookScript(command)), 0755)
+}
+
+// RepositoryRoot returns the top level directory of the repository in dir.
+func RepositoryRoot(ctx context.Context, dir string) (string, error) {
+	out, err := run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
@@ -0,0 +1,168 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/otms61/toggl"
+)
+
+type fakeTimerAPI struct {
+	running *toggl.TimeEntry
+	stopped []int
+	started []toggl.TimeEntryOptions
+}
+
+func (f *fakeTimerAPI) GetRunningTimeEntry(ctx context.Context) (*toggl.TimeEntry, error) {
+	return f.running, nil
+}
+
+func (f *fakeTimerAPI) StopTimeEntry(ctx context.Context, id int) error {
+	f.stopped = append(f.stopped, id)
+	f.running = nil
+	return nil
+}
+
+func (f *fakeTimerAPI) StartTimeEntryWithOptions(ctx context.Context, opts toggl.TimeEntryOptions) (*toggl.TimeEntry, error) {
+	f.started = append(f.started, opts)
+	f.running = &toggl.TimeEntry{ID: len(f.started) + 100, Description: opts.Description, Pid: opts.Pid, Duration: -1}
+	return f.running, nil
+}
+
+func TestIssueKey(t *testing.T) {
+	tests := map[string]string{
+		"feature/ABC-123-add-login": "ABC-123",
+		"abc-42":                    "ABC-42",
+		"feature/abc-42":            "ABC-42",
+		"fix/typo":                  "",
+		"hotfix/python-3-upgrade":   "",
+		"feature/abc-42-login":      "",
+	}
+	for branch, expected := range tests {
+		if got := IssueKey(branch); got != expected {
+			t.Errorf("%s: expected %q, got %q", branch, expected, got)
+		}
+	}
+}
+
+func TestBranchConfigResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), BranchConfigFile)
+	if _, err := LoadBranchConfig(path); err == nil {
+		t.Fatal("Expected an error for a missing configuration")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"tags": ["dev"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBranchConfig(path); err == nil {
+		t.Fatal("Expected an error for a configuration without wid or pid")
+	}
+	if _, _, err := (&BranchConfig{}).Resolve("XYZ-1"); err == nil {
+		t.Fatal("Expected an error resolving a configuration without wid or pid")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"wid": 1, "pid": 10, "tags": ["dev"], "projects": {"ABC": 20, "docs/*": 30}, "ignore": ["main", "release/*"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadBranchConfig(path)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	tests := []struct {
+		branch      string
+		track       bool
+		description string
+		pid         int
+	}{
+		{"feature/ABC-123-login", true, "ABC-123", 20},
+		{"XYZ-1", true, "XYZ-1", 10},
+		{"docs/readme", true, "docs/readme", 30},
+		{"main", false, "", 0},
+		{"release/1.0", false, "", 0},
+	}
+	for _, test := range tests {
+		opts, track, err := config.Resolve(test.branch)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.branch, err)
+			continue
+		}
+		if track != test.track || opts.Description != test.description || opts.Pid != test.pid {
+			t.Errorf("%s: unexpected options %+v, %v", test.branch, opts, track)
+		}
+		if track && (opts.Wid != 1 || !reflect.DeepEqual([]string{"dev"}, opts.Tags)) {
+			t.Errorf("%s: unexpected options %+v", test.branch, opts)
+		}
+	}
+}
+
+func TestSwitchBranch(t *testing.T) {
+	config := &BranchConfig{Wid: 1, Projects: map[string]int{"ABC": 20}}
+	api := &fakeTimerAPI{running: &toggl.TimeEntry{ID: 1, Description: "other", Duration: -1}}
+
+	running, err := SwitchBranch(context.Background(), api, config, "ABC-1-login")
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if running.Description != "ABC-1" || !reflect.DeepEqual([]int{1}, api.stopped) {
+		t.Fatalf("Unexpected switch to %+v, stopped %v", running, api.stopped)
+	}
+
+	// Checking out the same branch again keeps the timer.
+	if _, err := SwitchBranch(context.Background(), api, config, "ABC-1-login"); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if len(api.started) != 1 || len(api.stopped) != 1 {
+		t.Fatalf("Expected the timer to be kept, started %v and stopped %v", api.started, api.stopped)
+	}
+
+	// Ignored branches only stop the timer.
+	running, err = SwitchBranch(context.Background(), api, config, "master")
+	if err != nil || running != nil || len(api.started) != 1 || len(api.stopped) != 2 {
+		t.Fatalf("Unexpected switch to %+v, %v", running, err)
+	}
+}
+
+func TestSwitchBranchInvalidConfig(t *testing.T) {
+	api := &fakeTimerAPI{running: &toggl.TimeEntry{ID: 7, Description: "other", Duration: -1}}
+
+	if _, err := SwitchBranch(context.Background(), api, &BranchConfig{}, "feature/ABC-1"); err == nil {
+		t.Fatal("Expected an error for a configuration without wid or pid")
+	}
+	if len(api.stopped) != 0 || len(api.started) != 0 || api.running.ID != 7 {
+		t.Fatalf("Expected the timer to be kept, started %v and stopped %v", api.started, api.stopped)
+	}
+}
+
+func TestInstallHook(t *testing.T) {
+	dir := newTestRepository(t)
+
+	hook, err := InstallHook(context.Background(), dir, "toggl-branch")
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	b, err := os.ReadFile(hook)
+	if err != nil || string(b) != HookScript("toggl-branch") || !strings.Contains(string(b), `toggl-branch "$branch"`) {
+		t.Fatalf("Unexpected hook %s: %q, %v", hook, b, err)
+	}
+
+	// The generated hook is replaced, other hooks are kept.
+	if _, err := InstallHook(context.Background(), dir, "toggl-branch -dir ."); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := InstallHook(context.Background(), dir, "toggl-branch"); err == nil {
+		t.Fatal("Expected an error for an existing hook")
+	}
+}
@@ -0,0 +1,234 @@
+package git
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+// Match is a time entry with the commits made while it was tracked.
+type Match struct {
+	TimeEntry toggl.TimeEntry
+	Commits   []Commit
+}
+
+// Correlation holds the commits made within time entries and the others.
+type Correlation struct {
+	Matches   []Match
+	Untracked []Commit
+}
+
+// CorrelateOptions configures Correlate.
+type CorrelateOptions struct {
+	// Slack extends the time entries, counting the commits made shortly after
+	// they stopped. Defaults to 0.
+	Slack time.Duration
+	// Now ends the running entries. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Correlate matches the commits with the time entries tracked while they were
+// made. A commit within overlapping entries goes to the latest started one.
+// Matches are sorted by start, and commits by time.
+func Correlate(timeEntries []toggl.TimeEntry, commits []Commit, options *CorrelateOptions) *Correlation {
+	opts := CorrelateOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	now := opts.Now()
+
+	entries := append([]toggl.TimeEntry{}, timeEntries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Start.Before(entries[j].Start)
+	})
+	sorted := append([]Commit{}, commits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	result := &Correlation{Matches: []Match{}, Untracked: []Commit{}}
+	matched := map[int]int{}
+	for _, c := range sorted {
+		found := -1
+		for i, te := range entries {
+			if te.Start.After(c.Time) {
+				break
+			}
+			if !c.Time.After(te.EffectiveStop(now).Add(opts.Slack)) {
+				found = i
+			}
+		}
+		if found < 0 {
+			result.Untracked = append(result.Untracked, c)
+			continue
+		}
+
+		m, ok := matched[found]
+		if !ok {
+			m = len(result.Matches)
+			matched[found] = m
+			result.Matches = append(result.Matches, Match{TimeEntry: entries[found]})
+		}
+		result.Matches[m].Commits = append(result.Matches[m].Commits, c)
+	}
+
+	sort.SliceStable(result.Matches, func(i, j int) bool {
+		return result.Matches[i].TimeEntry.Start.Before(result.Matches[j].TimeEntry.Start)
+	})
+	return result
+}
+
+// API is the part of the client used to update the correlated time entries.
+type API interface {
+	GetTimeEntries(ctx context.Context, start, end time.Time) (*[]toggl.TimeEntry, error)
+	PatchTimeEntry(ctx context.Context, id int, update toggl.TimeEntryUpdate) (*toggl.TimeEntry, error)
+	BulkUpdateTimeEntriesTags(ctx context.Context, timeEntryIDs []int, tags []string, action toggl.TagAction) (*[]toggl.TimeEntry, error)
+}
+
+// CorrelateRange fetches the time entries around the commits and correlates them.
+// Time entries starting up to a day before the first commit are fetched.
+func CorrelateRange(ctx context.Context, api API, commits []Commit, options *CorrelateOptions) (*Correlation, error) {
+	if len(commits) == 0 {
+		return Correlate(nil, nil, options), nil
+	}
+
+	first, last := commits[0].Time, commits[0].Time
+	for _, c := range commits {
+		if c.Time.Before(first) {
+			first = c.Time
+		}
+		if c.Time.After(last) {
+			last = c.Time
+		}
+	}
+
+	timeEntries, err := api.GetTimeEntries(ctx, first.Add(-24*time.Hour), last.Add(time.Second))
+	if err != nil {
+		return nil, err
+	}
+	return Correlate(*timeEntries, commits, options), nil
+}
+
+// Annotation returns the description of the time entry followed by the short
+// hashes of its commits not mentioned yet, as "description [abc1234 def5678]".
+func (m Match) Annotation() string {
+	hashes := []string{}
+	for _, c := range m.Commits {
+		if !strings.Contains(m.TimeEntry.Description, c.ShortHash()) {
+			hashes = append(hashes, c.ShortHash())
+		}
+	}
+	if len(hashes) == 0 {
+		return m.TimeEntry.Description
+	}
+	return strings.TrimSpace(m.TimeEntry.Description + " [" + strings.Join(hashes, " ") + "]")
+}
+
+// Annotate appends the hashes of the commits to the descriptions of their time entries.
+// It returns the updated time entries.
+func Annotate(ctx context.Context, api API, matches []Match) ([]toggl.TimeEntry, error) {
+	updated := []toggl.TimeEntry{}
+	for _, m := range matches {
+		description := m.Annotation()
+		if description == m.TimeEntry.Description {
+			continue
+		}
+
+		te, err := api.PatchTimeEntry(ctx, m.TimeEntry.ID, toggl.TimeEntryUpdate{Description: toggl.String(description)})
+		if err != nil {
+			return updated, err
+		}
+		updated = append(updated, *te)
+	}
+	return updated, nil
+}
+
+// Tag adds the tag, like the name of the repository, to the matched time entries.
+// It returns the tagged time entries, along with the error when some could not be tagged.
+func Tag(ctx context.Context, api API, matches []Match, tag string) ([]toggl.TimeEntry, error) {
+	ids := []int{}
+	for _, m := range matches {
+		ids = append(ids, m.TimeEntry.ID)
+	}
+	if len(ids) == 0 {
+		return []toggl.TimeEntry{}, nil
+	}
+
+	timeEntries, err := api.BulkUpdateTimeEntriesTags(ctx, ids, []string{tag}, toggl.TagActionAdd)
+	if timeEntries == nil {
+		return nil, err
+	}
+	return *timeEntries, err
+}
+
+// Session is a proposed time entry for commits made while nothing was tracked.
+type Session struct {
+	Start   time.Time
+	Stop    time.Time
+	Commits []Commit
+	// Options create the time entry of the session.
+	Options toggl.TimeEntryOptions
+}
+
+// SessionOptions configures Sessions.
+type SessionOptions struct {
+	// Gap is the longest time between two commits of a session. Defaults to 2 hours.
+	Gap time.Duration
+	// Lead is the time worked before the first commit of a session. Defaults to 30 minutes.
+	Lead time.Duration
+	// Entry holds the workspace, project and tags of the proposed entries. The
+	// commit messages are used as description when it has none.
+	Entry toggl.TimeEntryOptions
+}
+
+// Sessions groups the commits into coding sessions, starting a new session
+// when commits are further apart than the gap. Sessions end at their last commit.
+func Sessions(commits []Commit, options *SessionOptions) []Session {
+	opts := SessionOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Gap == 0 {
+		opts.Gap = 2 * time.Hour
+	}
+	if opts.Lead == 0 {
+		opts.Lead = 30 * time.Minute
+	}
+
+	sorted := append([]Commit{}, commits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	sessions := []Session{}
+	for _, c := range sorted {
+		if n := len(sessions); n > 0 && c.Time.Sub(sessions[n-1].Stop) <= opts.Gap {
+			sessions[n-1].Stop = c.Time
+			sessions[n-1].Commits = append(sessions[n-1].Commits, c)
+			continue
+		}
+		sessions = append(sessions, Session{Start: c.Time.Add(-opts.Lead), Stop: c.Time, Commits: []Commit{c}})
+	}
+
+	for i := range sessions {
+		s := &sessions[i]
+		s.Options = opts.Entry
+		s.Options.Start = s.Start
+		s.Options.Stop = s.Stop
+		s.Options.Duration = 0
+		if s.Options.Description == "" {
+			messages := []string{}
+			for _, c := range s.Commits {
+				messages = append(messages, c.Message)
+			}
+			s.Options.Description = strings.Join(messages, "; ")
+		}
+	}
+	return sessions
+}
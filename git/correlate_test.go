@@ -0,0 +1,118 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+type fakeAPI struct {
+	entries []toggl.TimeEntry
+	patched map[int]toggl.TimeEntryUpdate
+	tagged  []int
+	// tagErr fails the tagging of the entries after the first one.
+	tagErr error
+}
+
+func (f *fakeAPI) GetTimeEntries(ctx context.Context, start, end time.Time) (*[]toggl.TimeEntry, error) {
+	return &f.entries, nil
+}
+
+func (f *fakeAPI) PatchTimeEntry(ctx context.Context, id int, update toggl.TimeEntryUpdate) (*toggl.TimeEntry, error) {
+	f.patched[id] = update
+	return &toggl.TimeEntry{ID: id, Description: *update.Description}, nil
+}
+
+func (f *fakeAPI) BulkUpdateTimeEntriesTags(ctx context.Context, timeEntryIDs []int, tags []string, action toggl.TagAction) (*[]toggl.TimeEntry, error) {
+	f.tagged = timeEntryIDs
+	if f.tagErr != nil {
+		return &[]toggl.TimeEntry{{ID: timeEntryIDs[0], Tags: tags}}, f.tagErr
+	}
+	return &[]toggl.TimeEntry{}, nil
+}
+
+func TestCorrelate(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2018, 4, 12, hour, min, 0, 0, time.UTC)
+	}
+	commit := func(hash string, t time.Time) Commit {
+		return Commit{Hash: hash + "000000", Time: t, Message: "commit " + hash}
+	}
+	api := &fakeAPI{
+		entries: []toggl.TimeEntry{
+			{ID: 1, Description: "coding", Start: at(9, 0), Stop: at(10, 0), Duration: 3600},
+			{ID: 2, Description: "review [b000000]", Start: at(11, 0), Duration: -int(at(11, 0).Unix())},
+		},
+		patched: map[int]toggl.TimeEntryUpdate{},
+	}
+	commits := []Commit{
+		commit("b", at(11, 30)),
+		commit("a", at(9, 30)),
+		// Within the slack of entry 1.
+		commit("c", at(10, 4)),
+		commit("d", at(14, 0)),
+		commit("e", at(15, 0)),
+		commit("f", at(20, 0)),
+	}
+
+	correlation, err := CorrelateRange(context.Background(), api, commits, &CorrelateOptions{
+		Slack: 5 * time.Minute,
+		Now:   func() time.Time { return at(12, 0) },
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if len(correlation.Matches) != 2 || len(correlation.Matches[0].Commits) != 2 || len(correlation.Matches[1].Commits) != 1 {
+		t.Fatalf("Unexpected matches %+v", correlation.Matches)
+	}
+	if len(correlation.Untracked) != 3 {
+		t.Fatalf("Unexpected untracked commits %+v", correlation.Untracked)
+	}
+
+	updated, err := Annotate(context.Background(), api, correlation.Matches)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if len(updated) != 1 || *api.patched[1].Description != "coding [a000000 c000000]" {
+		t.Fatalf("Unexpected annotations %+v", api.patched)
+	}
+
+	if _, err := Tag(context.Background(), api, correlation.Matches, "toggl"); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if !reflect.DeepEqual([]int{1, 2}, api.tagged) {
+		t.Fatalf("Unexpected tagged entries %v", api.tagged)
+	}
+
+	sessions := Sessions(correlation.Untracked, &SessionOptions{Gap: time.Hour, Entry: toggl.TimeEntryOptions{Pid: 10}})
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %+v", sessions)
+	}
+	expected := toggl.TimeEntryOptions{Pid: 10, Description: "commit d; commit e", Start: at(13, 30), Stop: at(15, 0)}
+	if !reflect.DeepEqual(expected, sessions[0].Options) || len(sessions[1].Commits) != 1 {
+		t.Fatalf("Expected session %+v, got %+v", expected, sessions[0].Options)
+	}
+	if err := sessions[0].Options.Validate(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
+func TestTagPartialFailure(t *testing.T) {
+	api := &fakeAPI{tagErr: errors.New("bulk operation failed for 1 time entries")}
+	matches := []Match{{TimeEntry: toggl.TimeEntry{ID: 1}}, {TimeEntry: toggl.TimeEntry{ID: 2}}}
+
+	tagged, err := Tag(context.Background(), api, matches, "toggl")
+	if err == nil {
+		t.Fatal("Expected an error for the untagged entry")
+	}
+	if len(tagged) != 1 || tagged[0].ID != 1 {
+		t.Fatalf("Expected the tagged entries along with the error, got %+v", tagged)
+	}
+}
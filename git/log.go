@@ -0,0 +1,154 @@
+// Package git correlates git activity with toggl time entries.
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	fieldSep  = "\x1f"
+	recordSep = "\x1e"
+	logFormat = "%H" + fieldSep + "%an" + fieldSep + "%ae" + fieldSep + "%aI" + fieldSep + "%S" + fieldSep + "%s" + recordSep
+)
+
+// Commit is a commit read from the log of a repository.
+type Commit struct {
+	Hash   string
+	Author string
+	Email  string
+	Time   time.Time
+	// Branch is the branch the commit was reached from.
+	Branch  string
+	Message string
+}
+
+// ShortHash returns the abbreviated hash of the commit.
+func (c Commit) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// LogOptions filters the commits read by Log.
+type LogOptions struct {
+	// Since and Until bound the author time of the commits, both included,
+	// unbounded when zero. Unlike git log --since and --until, which filter on
+	// the committer time, rebased and cherry-picked commits keep their time.
+	Since time.Time
+	Until time.Time
+	// Author matches the author name or email, as git log --author does.
+	Author string
+	// AllBranches reads the commits of every local branch instead of the current one.
+	AllBranches bool
+}
+
+// Log reads the commits of the repository in dir, most recent first.
+func Log(ctx context.Context, dir string, options *LogOptions) ([]Commit, error) {
+	opts := LogOptions{}
+	if options != nil {
+		opts = *options
+	}
+
+	args := []string{"log", "--source", "--format=" + logFormat}
+	if !opts.Since.IsZero() {
+		// git filters on the committer time, which is normally at or after the
+		// author time: a cheap first filter, refined below.
+		args = append(args, "--since="+opts.Since.Format(time.RFC3339))
+	}
+	if opts.Author != "" {
+		args = append(args, "--author="+opts.Author)
+	}
+	if opts.AllBranches {
+		args = append(args, "--branches")
+	}
+
+	out, err := run(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := parseLog(out)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []Commit{}
+	for _, c := range parsed {
+		if !opts.Since.IsZero() && c.Time.Before(opts.Since) {
+			continue
+		}
+		if !opts.Until.IsZero() && c.Time.After(opts.Until) {
+			continue
+		}
+		commits = append(commits, c)
+	}
+
+	// Without --branches the source is HEAD, resolved to the current branch.
+	if !opts.AllBranches {
+		branch, err := CurrentBranch(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		for i := range commits {
+			commits[i].Branch = branch
+		}
+	}
+
+	return commits, nil
+}
+
+// CurrentBranch returns the branch checked out in dir, "HEAD" when detached.
+func CurrentBranch(ctx context.Context, dir string) (string, error) {
+	out, err := run(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func parseLog(out string) ([]Commit, error) {
+	commits := []Commit{}
+	for _, record := range strings.Split(out, recordSep) {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+
+		fields := strings.SplitN(record, fieldSep, 6)
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("unexpected git log record %q", record)
+		}
+		at, err := time.Parse(time.RFC3339, fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("commit %s: %s", fields[0], err)
+		}
+
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Email:   fields[2],
+			Time:    at,
+			Branch:  strings.TrimPrefix(fields[4], "refs/heads/"),
+			Message: fields[5],
+		})
+	}
+	return commits, nil
+}
+
+func run(ctx context.Context, dir string, args ...string) (string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %s: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
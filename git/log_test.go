@@ -0,0 +1,122 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestRepository creates a repository with commits made at the given times on the branches.
+func newTestRepository(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(at string, args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=Alice", "-c", "user.email=alice@example.com"}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+at, "GIT_COMMITTER_DATE="+at)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s: %s", args, err, out)
+		}
+	}
+	commit := func(at, message string) {
+		if err := os.WriteFile(filepath.Join(dir, "file"), []byte(message), 0600); err != nil {
+			t.Fatal(err)
+		}
+		git(at, "add", "file")
+		git(at, "commit", "-q", "-m", message)
+	}
+
+	git("", "init", "-q", "-b", "main")
+	commit("2018-04-12T09:00:00Z", "Initial commit")
+	git("", "checkout", "-q", "-b", "feature")
+	commit("2018-04-12T10:00:00Z", "Add feature")
+	git("", "checkout", "-q", "main")
+	commit("2018-04-12T11:00:00Z", "Fix bug")
+
+	return dir
+}
+
+func TestLog(t *testing.T) {
+	dir := newTestRepository(t)
+
+	commits, err := Log(context.Background(), dir, nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	got := []string{}
+	for _, c := range commits {
+		if c.Author != "Alice" || c.Email != "alice@example.com" || len(c.ShortHash()) != 7 {
+			t.Fatalf("Unexpected commit %+v", c)
+		}
+		got = append(got, c.Time.UTC().Format("15:04")+" "+c.Branch+" "+c.Message)
+	}
+	expected := []string{"11:00 main Fix bug", "09:00 main Initial commit"}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected commits %q, got %q", expected, got)
+	}
+
+	commits, err = Log(context.Background(), dir, &LogOptions{
+		AllBranches: true,
+		Since:       time.Date(2018, 4, 12, 9, 30, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	got = []string{}
+	for _, c := range commits {
+		got = append(got, c.Branch+" "+c.Message)
+	}
+	expected = []string{"main Fix bug", "feature Add feature"}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected commits %q, got %q", expected, got)
+	}
+
+	if _, err := Log(context.Background(), t.TempDir(), nil); err == nil {
+		t.Fatal("Expected an error outside a repository")
+	}
+}
+
+func TestLogAuthorTime(t *testing.T) {
+	dir := newTestRepository(t)
+
+	// A rebased commit keeps its author time but gets a new committer time.
+	cmd := exec.Command("git", "-C", dir, "-c", "user.name=Alice", "-c", "user.email=alice@example.com", "commit", "-q", "--allow-empty", "-m", "Rebased")
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE=2018-04-12T12:00:00Z", "GIT_COMMITTER_DATE=2018-04-20T12:00:00Z")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %s: %s", err, out)
+	}
+
+	commits, err := Log(context.Background(), dir, &LogOptions{
+		Since: time.Date(2018, 4, 12, 10, 30, 0, 0, time.UTC),
+		Until: time.Date(2018, 4, 13, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	got := []string{}
+	for _, c := range commits {
+		got = append(got, c.Message)
+	}
+	expected := []string{"Rebased", "Fix bug"}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected commits %q, got %q", expected, got)
+	}
+
+	commits, err = Log(context.Background(), dir, &LogOptions{Since: time.Date(2018, 4, 15, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if len(commits) != 0 {
+		t.Fatalf("Expected no commits, got %+v", commits)
+	}
+}
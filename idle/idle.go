@@ -0,0 +1,156 @@
+// Package idle stops the running time entry when the user has gone idle.
+package idle
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+// Source tells when the user was last active, like an X11 idle query.
+type Source interface {
+	// LastActivity returns the time of the last user activity, zero when unknown.
+	LastActivity(ctx context.Context) (time.Time, error)
+}
+
+// SourceFunc adapts a function to a Source.
+type SourceFunc func(ctx context.Context) (time.Time, error)
+
+// LastActivity calls f.
+func (f SourceFunc) LastActivity(ctx context.Context) (time.Time, error) {
+	return f(ctx)
+}
+
+// FileHeartbeat is a Source reading the modification time of a file touched on every activity.
+type FileHeartbeat struct {
+	Path string
+}
+
+// LastActivity returns the modification time of the file, zero when it does not exist.
+func (h FileHeartbeat) LastActivity(ctx context.Context) (time.Time, error) {
+	info, err := os.Stat(h.Path)
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// API is the part of the client used by the watcher.
+type API interface {
+	GetRunningTimeEntry(ctx context.Context) (*toggl.TimeEntry, error)
+	StopTimeEntryAt(ctx context.Context, id int, stop time.Time) (*toggl.TimeEntry, error)
+}
+
+// Stop reports a time entry stopped because the user went idle.
+type Stop struct {
+	// TimeEntry is the stopped time entry.
+	TimeEntry *toggl.TimeEntry
+	// IdleSince is the last activity, which the time entry was stopped at.
+	IdleSince time.Time
+}
+
+// Config configures a Watcher.
+type Config struct {
+	// Threshold is how long the user must be idle. Defaults to 15 minutes.
+	Threshold time.Duration
+	// Interval is the time between checks of Run. Defaults to a minute.
+	Interval time.Duration
+	// Notify is called with every stopped time entry.
+	Notify func(Stop)
+	// OnError is called with the errors of the checks of Run, which keeps
+	// watching. Without it Run returns the first error.
+	OnError func(error)
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+func (c Config) withDefaults() Config {
+	if c.Threshold == 0 {
+		c.Threshold = 15 * time.Minute
+	}
+	if c.Interval == 0 {
+		c.Interval = time.Minute
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+	return c
+}
+
+// Watcher stops the running time entry at the last activity of the user once
+// the user has been idle beyond the threshold.
+type Watcher struct {
+	api    API
+	source Source
+	config Config
+}
+
+// NewWatcher builds a watcher.
+func NewWatcher(api API, source Source, config Config) *Watcher {
+	return &Watcher{
+		api:    api,
+		source: source,
+		config: config.withDefaults(),
+	}
+}
+
+// Check stops the running time entry if the user is idle beyond the threshold.
+// Time entries started after the last activity, from another device, are left
+// running. It returns nil without error when nothing was stopped.
+func (w *Watcher) Check(ctx context.Context) (*Stop, error) {
+	last, err := w.source.LastActivity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if last.IsZero() || w.config.Now().Sub(last) < w.config.Threshold {
+		return nil, nil
+	}
+
+	running, err := w.api.GetRunningTimeEntry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if running == nil || !last.After(running.Start) {
+		return nil, nil
+	}
+
+	stopped, err := w.api.StopTimeEntryAt(ctx, running.ID, last)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := &Stop{TimeEntry: stopped, IdleSince: last}
+	if w.config.Notify != nil {
+		w.config.Notify(*stop)
+	}
+	return stop, nil
+}
+
+// Run checks at every interval until the context is done.
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.Check(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if w.config.OnError == nil {
+				return err
+			}
+			w.config.OnError(err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
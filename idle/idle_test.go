@@ -0,0 +1,121 @@
+package idle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+type fakeAPI struct {
+	running *toggl.TimeEntry
+	stops   []time.Time
+}
+
+func (f *fakeAPI) GetRunningTimeEntry(ctx context.Context) (*toggl.TimeEntry, error) {
+	return f.running, nil
+}
+
+func (f *fakeAPI) StopTimeEntryAt(ctx context.Context, id int, stop time.Time) (*toggl.TimeEntry, error) {
+	f.stops = append(f.stops, stop)
+	te := *f.running
+	te.Stop = stop
+	te.Duration = int(stop.Sub(te.Start).Seconds())
+	f.running = nil
+	return &te, nil
+}
+
+func TestWatcherCheck(t *testing.T) {
+	start := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	last := start.Add(time.Hour)
+	now := last.Add(10 * time.Minute)
+	api := &fakeAPI{running: &toggl.TimeEntry{ID: 1, Start: start, Duration: -int(start.Unix())}}
+
+	notified := []Stop{}
+	w := NewWatcher(api, SourceFunc(func(ctx context.Context) (time.Time, error) {
+		return last, nil
+	}), Config{
+		Notify: func(s Stop) { notified = append(notified, s) },
+		Now:    func() time.Time { return now },
+	})
+
+	// Idle below the threshold.
+	stop, err := w.Check(context.Background())
+	if err != nil || stop != nil {
+		t.Fatalf("Unexpected result %+v, %v", stop, err)
+	}
+
+	now = last.Add(8 * time.Hour)
+	stop, err = w.Check(context.Background())
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if stop == nil || stop.TimeEntry.Duration != 3600 || !stop.IdleSince.Equal(last) || len(notified) != 1 {
+		t.Fatalf("Expected the time entry to be stopped at the last activity, got %+v", stop)
+	}
+
+	// Nothing is running anymore.
+	stop, err = w.Check(context.Background())
+	if err != nil || stop != nil || len(api.stops) != 1 {
+		t.Fatalf("Unexpected result %+v, %v", stop, err)
+	}
+
+	// Time entries started while idle are left running.
+	api.running = &toggl.TimeEntry{ID: 2, Start: last.Add(time.Hour), Duration: -int(last.Add(time.Hour).Unix())}
+	stop, err = w.Check(context.Background())
+	if err != nil || stop != nil || len(api.stops) != 1 {
+		t.Fatalf("Unexpected result %+v, %v", stop, err)
+	}
+}
+
+func TestFileHeartbeat(t *testing.T) {
+	h := FileHeartbeat{Path: filepath.Join(t.TempDir(), "heartbeat")}
+
+	last, err := h.LastActivity(context.Background())
+	if err != nil || !last.IsZero() {
+		t.Fatalf("Unexpected result %s, %v", last, err)
+	}
+
+	at := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	if err := os.WriteFile(h.Path, nil, 0600); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if err := os.Chtimes(h.Path, at, at); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	last, err = h.LastActivity(context.Background())
+	if err != nil || !last.Equal(at) {
+		t.Fatalf("Expected %s, got %s, %v", at, last, err)
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	failing := errors.New("unavailable")
+	source := SourceFunc(func(ctx context.Context) (time.Time, error) {
+		return time.Time{}, failing
+	})
+
+	w := NewWatcher(&fakeAPI{}, source, Config{Interval: time.Millisecond})
+	if err := w.Run(context.Background()); err != failing {
+		t.Fatalf("Expected the source error, got %v", err)
+	}
+
+	errs := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	w = NewWatcher(&fakeAPI{}, source, Config{Interval: time.Millisecond, OnError: func(err error) {
+		errs++
+		if errs == 3 {
+			cancel()
+		}
+	}})
+	if err := w.Run(ctx); err != context.Canceled || errs != 3 {
+		t.Fatalf("Expected the watcher to keep running, got %v after %d errors", err, errs)
+	}
+}
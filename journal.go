@@ -0,0 +1,419 @@
+package toggl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// JournalOpType is the kind of an operation recorded in a Journal.
+type JournalOpType string
+
+// Operations recorded by a Journal.
+const (
+	JournalStart  JournalOpType = "start"
+	JournalStop   JournalOpType = "stop"
+	JournalCreate JournalOpType = "create"
+	JournalUpdate JournalOpType = "update"
+	JournalDelete JournalOpType = "delete"
+)
+
+// JournalOp is an operation recorded while offline.
+type JournalOp struct {
+	Type JournalOpType `json:"type"`
+	// GUID identifies the time entry the operation applies to.
+	GUID string `json:"guid"`
+	// At is when the operation was recorded. Start and stop operations are replayed at that time.
+	At      time.Time         `json:"at"`
+	Options *TimeEntryOptions `json:"options,omitempty"`
+	Update  *TimeEntryUpdate  `json:"update,omitempty"`
+	// Sent is set once the creation of the time entry was sent to the server,
+	// which may have created it even though no response came back.
+	Sent bool `json:"sent,omitempty"`
+}
+
+// JournalConflict is an operation skipped during a sync because the server state changed.
+type JournalConflict struct {
+	Op     JournalOp
+	Reason string
+}
+
+// JournalReport reports the outcome of a Journal sync.
+type JournalReport struct {
+	Applied   []JournalOp
+	Conflicts []JournalConflict
+	// Pending holds the operations left in the journal because the sync stopped on an error.
+	Pending []JournalOp
+}
+
+// Journal records time entry operations on disk while offline, and replays them
+// in order once the server is reachable. Time entries are identified by client
+// generated GUIDs, mapped to the server IDs as the operations are replayed.
+type Journal struct {
+	path string
+	now  func() time.Time
+
+	mu  sync.Mutex
+	ops []JournalOp
+	ids map[string]int
+}
+
+type journalFile struct {
+	Ops []JournalOp    `json:"ops"`
+	IDs map[string]int `json:"ids"`
+}
+
+// OpenJournal opens the journal stored at path, creating it on the first record.
+func OpenJournal(path string) (*Journal, error) {
+	j := &Journal{
+		path: path,
+		now:  time.Now,
+		ops:  []JournalOp{},
+		ids:  map[string]int{},
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f := journalFile{}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("reading journal %s: %s", path, err)
+	}
+	if f.Ops != nil {
+		j.ops = f.Ops
+	}
+	if f.IDs != nil {
+		j.ids = f.IDs
+	}
+
+	return j, nil
+}
+
+// Ops returns the operations waiting to be synced.
+func (j *Journal) Ops() []JournalOp {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return append([]JournalOp{}, j.ops...)
+}
+
+// Track returns a GUID referring to a time entry already known by the server,
+// so that it can be stopped, updated or deleted offline.
+func (j *Journal) Track(id int) (string, error) {
+	guid, err := newGUID()
+	if err != nil {
+		return "", err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.ids[guid] = id
+	return guid, j.saveLocked()
+}
+
+// Start records the start of a time entry now, unless opts has a start, and returns its GUID.
+func (j *Journal) Start(opts TimeEntryOptions) (string, error) {
+	if err := opts.validate(true); err != nil {
+		return "", err
+	}
+
+	at := j.now()
+	if !opts.Start.IsZero() {
+		at = opts.Start
+	}
+	opts.Start = at
+
+	return j.recordNew(JournalOp{Type: JournalStart, At: at, Options: &opts})
+}
+
+// Create records the creation of a stopped time entry and returns its GUID.
+func (j *Journal) Create(opts TimeEntryOptions) (string, error) {
+	if err := opts.validate(false); err != nil {
+		return "", err
+	}
+
+	return j.recordNew(JournalOp{Type: JournalCreate, At: j.now(), Options: &opts})
+}
+
+// Stop records the stop of the time entry now.
+func (j *Journal) Stop(guid string) error {
+	return j.record(JournalOp{Type: JournalStop, GUID: guid, At: j.now()})
+}
+
+// Update records a partial update of the time entry.
+func (j *Journal) Update(guid string, update TimeEntryUpdate) error {
+	if err := update.validate(); err != nil {
+		return err
+	}
+
+	return j.record(JournalOp{Type: JournalUpdate, GUID: guid, At: j.now(), Update: &update})
+}
+
+// Delete records the deletion of the time entry.
+func (j *Journal) Delete(guid string) error {
+	return j.record(JournalOp{Type: JournalDelete, GUID: guid, At: j.now()})
+}
+
+func (j *Journal) recordNew(op JournalOp) (string, error) {
+	guid, err := newGUID()
+	if err != nil {
+		return "", err
+	}
+	op.GUID = guid
+
+	return guid, j.record(op)
+}
+
+func (j *Journal) record(op JournalOp) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if op.Type != JournalStart && op.Type != JournalCreate && !j.knownLocked(op.GUID) {
+		return fmt.Errorf("unknown time entry %s", op.GUID)
+	}
+
+	j.ops = append(j.ops, op)
+	return j.saveLocked()
+}
+
+func (j *Journal) knownLocked(guid string) bool {
+	if _, ok := j.ids[guid]; ok {
+		return true
+	}
+	for _, op := range j.ops {
+		if op.GUID == guid {
+			return true
+		}
+	}
+	return false
+}
+
+// Sync replays the recorded operations in order. Operations the server state
+// conflicts with are skipped and reported. On any other error the sync stops,
+// and the remaining operations stay in the journal for the next sync.
+func (j *Journal) Sync(ctx context.Context, c *Client) (*JournalReport, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	report := &JournalReport{
+		Applied:   []JournalOp{},
+		Conflicts: []JournalConflict{},
+		Pending:   []JournalOp{},
+	}
+
+	for len(j.ops) > 0 {
+		op := j.ops[0]
+
+		if (op.Type == JournalStart || op.Type == JournalCreate) && !op.Sent {
+			j.ops[0].Sent = true
+			if err := j.saveLocked(); err != nil {
+				report.Pending = append(report.Pending, j.ops...)
+				return report, err
+			}
+		}
+
+		conflict, err := j.replayLocked(ctx, c, op)
+		if err != nil {
+			report.Pending = append(report.Pending, j.ops...)
+			return report, err
+		}
+		if conflict != "" {
+			report.Conflicts = append(report.Conflicts, JournalConflict{Op: op, Reason: conflict})
+		} else {
+			report.Applied = append(report.Applied, op)
+		}
+
+		j.ops = j.ops[1:]
+		if err := j.saveLocked(); err != nil {
+			report.Pending = append(report.Pending, j.ops...)
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// replayLocked applies the operation to the server. It returns the reason
+// the operation conflicts with the server state, if it does.
+func (j *Journal) replayLocked(ctx context.Context, c *Client, op JournalOp) (string, error) {
+	if op.Type == JournalStart || op.Type == JournalCreate {
+		return j.replayNewLocked(ctx, c, op)
+	}
+
+	id, ok := j.ids[op.GUID]
+	if !ok {
+		return fmt.Sprintf("time entry %s was never created on the server", op.GUID), nil
+	}
+
+	switch op.Type {
+	case JournalStop:
+		timeEntry, err := c.GetTimeEntry(ctx, id)
+		if isNotFound(err) {
+			return fmt.Sprintf("time entry %d was deleted on the server", id), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if !timeEntry.IsRunning() {
+			return fmt.Sprintf("time entry %d was already stopped on the server", id), nil
+		}
+		if !op.At.After(timeEntry.Start) {
+			return fmt.Sprintf("time entry %d starts after the stop", id), nil
+		}
+		_, err = c.StopTimeEntryAt(ctx, id, op.At)
+		return "", err
+
+	case JournalUpdate:
+		_, err := c.PatchTimeEntry(ctx, id, *op.Update)
+		if isNotFound(err) {
+			return fmt.Sprintf("time entry %d was deleted on the server", id), nil
+		}
+		return "", err
+
+	case JournalDelete:
+		err := c.DeleteTimeEntry(ctx, id)
+		if isNotFound(err) {
+			return fmt.Sprintf("time entry %d was already deleted on the server", id), nil
+		}
+		if err == nil {
+			delete(j.ids, op.GUID)
+		}
+		return "", err
+	}
+
+	return fmt.Sprintf("unknown operation %q", op.Type), nil
+}
+
+func (j *Journal) replayNewLocked(ctx context.Context, c *Client, op JournalOp) (string, error) {
+	if op.Options == nil {
+		return "operation without time entry options", nil
+	}
+	opts := *op.Options
+	opts.GUID = op.GUID
+
+	// A previous sync may have created the time entry without getting the response.
+	if op.Sent {
+		timeEntry, err := findTimeEntryByGUID(ctx, c, op.GUID, opts.Start)
+		if err != nil {
+			return "", err
+		}
+		if timeEntry != nil {
+			j.ids[op.GUID] = timeEntry.ID
+			return "", nil
+		}
+	}
+
+	if op.Type == JournalCreate {
+		timeEntry, err := c.CreateTimeEntryWithOptions(ctx, opts)
+		if err != nil {
+			return "", err
+		}
+		j.ids[op.GUID] = timeEntry.ID
+		return "", nil
+	}
+
+	// Starting a time entry stops the running one, as it would have online.
+	// A time entry started on the server after the offline start wins, and the
+	// offline entry is created stopped when the server entry started.
+	conflict := ""
+	running, err := c.GetRunningTimeEntry(ctx)
+	if err != nil {
+		return "", err
+	}
+	if running != nil {
+		if running.Start.Before(opts.Start) {
+			if _, err := c.StopTimeEntryAt(ctx, running.ID, opts.Start); err != nil {
+				return "", err
+			}
+		} else {
+			opts.Stop = running.Start
+			if stop, ok := j.pendingStopLocked(op.GUID); ok && stop.Before(opts.Stop) {
+				opts.Stop = stop
+			}
+			conflict = fmt.Sprintf("time entry %d was started on the server meanwhile, the entry was stopped at its start", running.ID)
+		}
+	}
+
+	var timeEntry *TimeEntry
+	if opts.Stop.IsZero() {
+		timeEntry, err = c.StartTimeEntryWithOptions(ctx, opts)
+	} else if opts.Stop.After(opts.Start) {
+		timeEntry, err = c.CreateTimeEntryWithOptions(ctx, opts)
+	} else {
+		return fmt.Sprintf("time entry %d was started on the server before the entry", running.ID), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	j.ids[op.GUID] = timeEntry.ID
+
+	return conflict, nil
+}
+
+func (j *Journal) pendingStopLocked(guid string) (time.Time, bool) {
+	for _, op := range j.ops {
+		if op.GUID == guid && op.Type == JournalStop {
+			return op.At, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (j *Journal) saveLocked() error {
+	b, err := json.Marshal(journalFile{Ops: j.ops, IDs: j.ids})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0700); err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+// findTimeEntryByGUID looks for the time entry with the GUID among the ones
+// starting around start. It returns nil without error when there is none.
+func findTimeEntryByGUID(ctx context.Context, c *Client, guid string, start time.Time) (*TimeEntry, error) {
+	timeEntries, err := c.GetTimeEntries(ctx, start.Add(-time.Minute), start.Add(time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	for _, te := range *timeEntries {
+		if te.GUID == guid {
+			te := te
+			return &te, nil
+		}
+	}
+	return nil, nil
+}
+
+func isNotFound(err error) bool {
+	var statusErr statusCodeError
+	return errors.As(err, &statusErr) && statusErr.Code == http.StatusNotFound
+}
+
+func newGUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,365 @@
+package toggl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTimeEntryServer keeps time entries in memory and serves the time entries endpoints.
+type fakeTimeEntryServer struct {
+	entries map[int]TimeEntry
+	nextID  int
+	offline bool
+}
+
+func newFakeTimeEntryServer(entries ...TimeEntry) *fakeTimeEntryServer {
+	s := &fakeTimeEntryServer{entries: map[int]TimeEntry{}, nextID: 100}
+	for _, te := range entries {
+		s.entries[te.ID] = te
+	}
+	return s
+}
+
+func (s *fakeTimeEntryServer) client() *http.Client {
+	return newMockClient(func(req *http.Request) (*http.Response, error) {
+		if s.offline {
+			return nil, errors.New("network is unreachable")
+		}
+
+		path := strings.TrimPrefix(req.URL.Path, "/api/v8/time_entries")
+		path = strings.TrimPrefix(path, "/")
+
+		var v interface{}
+		switch {
+		case req.Method == "GET" && path == "current":
+			var running *TimeEntry
+			for _, te := range s.entries {
+				if te.IsRunning() {
+					te := te
+					running = &te
+				}
+			}
+			v = struct {
+				Data *TimeEntry `json:"data"`
+			}{Data: running}
+
+		case req.Method == "GET" && path == "":
+			start, err := time.Parse(time.RFC3339, req.URL.Query().Get("start_date"))
+			if err != nil {
+				return nil, err
+			}
+			end, err := time.Parse(time.RFC3339, req.URL.Query().Get("end_date"))
+			if err != nil {
+				return nil, err
+			}
+			entries := []TimeEntry{}
+			for _, te := range s.entries {
+				if !te.Start.Before(start) && te.Start.Before(end) {
+					entries = append(entries, te)
+				}
+			}
+			v = entries
+
+		case req.Method == "POST":
+			te := TimeEntry{ID: s.nextID}
+			s.nextID++
+			if err := s.apply(&te, req); err != nil {
+				return nil, err
+			}
+			if path == "start" {
+				te.Start = time.Date(2018, 4, 12, 12, 0, 0, 0, time.UTC)
+				te.Duration = -int(te.Start.Unix())
+			}
+			s.entries[te.ID] = te
+			v = struct {
+				Data TimeEntry `json:"data"`
+			}{Data: te}
+
+		default:
+			id, err := strconv.Atoi(path)
+			if err != nil {
+				return nil, fmt.Errorf("Unexpected request %s %s", req.Method, req.URL.Path)
+			}
+			te, ok := s.entries[id]
+			if !ok {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Status:     "404 Not Found",
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				}, nil
+			}
+
+			switch req.Method {
+			case "GET":
+			case "PUT":
+				if err := s.apply(&te, req); err != nil {
+					return nil, err
+				}
+				s.entries[id] = te
+			case "DELETE":
+				delete(s.entries, id)
+				v = []int{id}
+			}
+			if v == nil {
+				v = struct {
+					Data TimeEntry `json:"data"`
+				}{Data: te}
+			}
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+	})
+}
+
+func (s *fakeTimeEntryServer) apply(te *TimeEntry, req *http.Request) error {
+	body := struct {
+		TimeEntry struct {
+			GUID        *string    `json:"guid"`
+			Description *string    `json:"description"`
+			Tags        *[]string  `json:"tags"`
+			Start       *time.Time `json:"start"`
+			Stop        *time.Time `json:"stop"`
+			Duration    *int       `json:"duration"`
+			Pid         *int       `json:"pid"`
+			Wid         *int       `json:"wid"`
+		} `json:"time_entry"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		return err
+	}
+
+	u := body.TimeEntry
+	if u.GUID != nil {
+		te.GUID = *u.GUID
+	}
+	if u.Description != nil {
+		te.Description = *u.Description
+	}
+	if u.Tags != nil {
+		te.Tags = *u.Tags
+	}
+	if u.Start != nil {
+		te.Start = *u.Start
+	}
+	if u.Stop != nil {
+		te.Stop = *u.Stop
+	}
+	if u.Duration != nil {
+		te.Duration = *u.Duration
+	}
+	if u.Pid != nil {
+		te.Pid = *u.Pid
+	}
+	if u.Wid != nil {
+		te.Wid = *u.Wid
+	}
+	return nil
+}
+
+func TestJournalSync(t *testing.T) {
+	base := time.Date(2018, 4, 12, 8, 0, 0, 0, time.UTC)
+	now := base
+	server := newFakeTimeEntryServer(TimeEntry{ID: 1, Pid: 10, Description: "online", Start: base.Add(-time.Hour), Duration: -int(base.Add(-time.Hour).Unix())})
+	api := New("test", OptionHTTPClient(server.client()))
+
+	path := filepath.Join(t.TempDir(), "journal.json")
+	journal, err := OpenJournal(path)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	journal.now = func() time.Time { return now }
+
+	online, err := journal.Track(1)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	started, err := journal.Start(TimeEntryOptions{Pid: 10, Description: "offline"})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	now = base.Add(30 * time.Minute)
+	if err := journal.Stop(started); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if err := journal.Update(started, TimeEntryUpdate{Description: String("offline work")}); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	created, err := journal.Create(TimeEntryOptions{Pid: 10, Start: base.Add(time.Hour), Duration: time.Hour})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if err := journal.Delete(created); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if err := journal.Stop("unknown"); err == nil {
+		t.Fatal(errors.New("Expected an error for an unknown time entry"))
+	}
+
+	// The journal survives a restart.
+	journal, err = OpenJournal(path)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if len(journal.Ops()) != 5 {
+		t.Fatalf("Expected 5 operations, got %d", len(journal.Ops()))
+	}
+
+	server.offline = true
+	report, err := journal.Sync(context.Background(), api)
+	if err == nil || len(report.Pending) != 5 || len(journal.Ops()) != 5 {
+		t.Fatal(errors.New("Expected the operations to stay pending while offline"))
+	}
+
+	server.offline = false
+	report, err = journal.Sync(context.Background(), api)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if len(report.Applied) != 5 || len(report.Conflicts) != 0 || len(journal.Ops()) != 0 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+
+	expected := map[int]TimeEntry{
+		1:   {ID: 1, Pid: 10, Description: "online", Start: base.Add(-time.Hour), Stop: base, Duration: 3600},
+		100: {ID: 100, GUID: started, Pid: 10, Description: "offline work", Start: base, Stop: base.Add(30 * time.Minute), Duration: 1800},
+	}
+	for id, te := range server.entries {
+		te.Start = te.Start.UTC()
+		te.Stop = te.Stop.UTC()
+		server.entries[id] = te
+	}
+	if !reflect.DeepEqual(expected, server.entries) {
+		t.Fatalf("Expected entries %+v, got %+v", expected, server.entries)
+	}
+
+	// Operations on time entries deleted on the server are reported as conflicts.
+	server.entries = map[int]TimeEntry{}
+	if err := journal.Delete(online); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	report, err = journal.Sync(context.Background(), api)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if len(report.Applied) != 0 || len(report.Conflicts) != 1 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+}
+
+func TestJournalSyncStartedMeanwhile(t *testing.T) {
+	base := time.Date(2018, 4, 12, 8, 0, 0, 0, time.UTC)
+	now := base
+	server := newFakeTimeEntryServer()
+	api := New("test", OptionHTTPClient(server.client()))
+
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "journal.json"))
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	journal.now = func() time.Time { return now }
+
+	if _, err := journal.Start(TimeEntryOptions{Wid: 1, Description: "offline"}); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	// Someone started a time entry online after the offline start.
+	server.entries[1] = TimeEntry{ID: 1, Description: "online", Start: base.Add(time.Hour), Duration: -int(base.Add(time.Hour).Unix())}
+
+	report, err := journal.Sync(context.Background(), api)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if len(report.Conflicts) != 1 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+
+	offline := server.entries[100]
+	if offline.IsRunning() || !offline.Stop.Equal(base.Add(time.Hour)) || !server.entries[1].IsRunning() {
+		t.Fatalf("Unexpected entries %+v", server.entries)
+	}
+}
+
+func TestJournalSyncLostResponse(t *testing.T) {
+	base := time.Date(2018, 4, 12, 8, 0, 0, 0, time.UTC)
+	server := newFakeTimeEntryServer()
+	inner := server.client()
+	lost := 0
+	api := New("test", OptionHTTPClient(newMockClient(func(req *http.Request) (*http.Response, error) {
+		resp, err := inner.Transport.RoundTrip(req)
+		// The first creation reaches the server, but its response is lost.
+		if req.Method == "POST" && lost == 0 {
+			lost++
+			return nil, errors.New("connection reset by peer")
+		}
+		return resp, err
+	})))
+
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "journal.json"))
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	journal.now = func() time.Time { return base }
+
+	created, err := journal.Create(TimeEntryOptions{Pid: 10, Description: "offline", Start: base, Duration: time.Hour})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if err := journal.Update(created, TimeEntryUpdate{Description: String("offline work")}); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	if _, err := journal.Sync(context.Background(), api); err == nil {
+		t.Fatal(errors.New("Expected the lost response to stop the sync"))
+	}
+	if len(server.entries) != 1 || len(journal.Ops()) != 2 {
+		t.Fatalf("Expected the entry to be created and the operations pending, got %+v", server.entries)
+	}
+
+	report, err := journal.Sync(context.Background(), api)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if len(report.Applied) != 2 || len(server.entries) != 1 {
+		t.Fatalf("Expected the entry not to be created again, got %+v", server.entries)
+	}
+	if te := server.entries[100]; te.GUID != created || te.Description != "offline work" {
+		t.Fatalf("Expected the update to apply to the created entry, got %+v", te)
+	}
+}
@@ -0,0 +1,62 @@
+package toggl
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Me contain all the information of the token owner, and the related data when requested.
+type Me struct {
+	ID          int         `json:"id"`
+	APIToken    string      `json:"api_token"`
+	DefaultWid  int         `json:"default_wid"`
+	Email       string      `json:"email"`
+	Fullname    string      `json:"fullname"`
+	Timezone    string      `json:"timezone"`
+	At          time.Time   `json:"at"`
+	Workspaces  []Workspace `json:"workspaces"`
+	Projects    []Project   `json:"projects"`
+	Tags        []Tag       `json:"tags"`
+	TimeEntries []TimeEntry `json:"time_entries"`
+}
+
+type meResponse struct {
+	Since int64 `json:"since"`
+	Data  Me    `json:"data"`
+}
+
+// GetMe will retrive the token owner.
+func (c *Client) GetMe(ctx context.Context) (*Me, error) {
+	spath := "v8/me"
+	response := &meResponse{}
+
+	err := c.get(ctx, spath, nil, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// GetMeRelatedData will retrive the token owner with the workspaces, projects, tags and time entries
+// changed since the given unix time, or all of them when since is 0. It returns the since to
+// pass to the next call as well.
+func (c *Client) GetMeRelatedData(ctx context.Context, since int64) (*Me, int64, error) {
+	spath := "v8/me"
+	response := &meResponse{}
+
+	params := url.Values{}
+	params.Add("with_related_data", "true")
+	if since != 0 {
+		params.Add("since", strconv.FormatInt(since, 10))
+	}
+
+	err := c.get(ctx, spath, params, response)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &response.Data, response.Since, nil
+}
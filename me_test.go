@@ -0,0 +1,106 @@
+package toggl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func getTestMe() Me {
+	return Me{
+		ID:         2941647,
+		APIToken:   "test",
+		DefaultWid: 3278506,
+		Email:      "toggl@example.com",
+		Fullname:   "toggl test",
+		Timezone:   "Asia/Tokyo",
+		At:         time.Date(2018, 4, 12, 7, 49, 15, 0, time.UTC),
+	}
+}
+
+func TestGetMe(t *testing.T) {
+	expected := getTestMe()
+	expectedURL := "/api/v8/me"
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if !strings.HasPrefix(req.URL.Path, expectedURL) {
+			return nil, fmt.Errorf("Expected URL '%s', got %s", expectedURL, req.URL.Path)
+		}
+
+		b, err := json.Marshal(struct {
+			Since int64 `json:"since"`
+			Data  Me    `json:"data"`
+		}{
+			Since: 1523519355,
+			Data:  getTestMe(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+
+	})
+	api := New("test", OptionHTTPClient(client))
+
+	me, err := api.GetMe(context.Background())
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if !reflect.DeepEqual(expected, *me) {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+}
+
+func TestGetMeRelatedData(t *testing.T) {
+	expectedURL := "/api/v8/me"
+	expectedQuery := "since=1523519355&with_related_data=true"
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if !strings.HasPrefix(req.URL.Path, expectedURL) {
+			return nil, fmt.Errorf("Expected URL '%s', got %s", expectedURL, req.URL.Path)
+		}
+		if req.URL.RawQuery != expectedQuery {
+			return nil, fmt.Errorf("Expected query '%s', got %s", expectedQuery, req.URL.RawQuery)
+		}
+
+		b, err := json.Marshal(struct {
+			Since int64 `json:"since"`
+			Data  Me    `json:"data"`
+		}{
+			Since: 1523519400,
+			Data:  getTestMe(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+
+	})
+	api := New("test", OptionHTTPClient(client))
+
+	_, since, err := api.GetMeRelatedData(context.Background(), 1523519355)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if since != 1523519400 {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+}
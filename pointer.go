@@ -0,0 +1,32 @@
+package toggl
+
+import "time"
+
+// String returns a pointer to the string value, for the optional fields of update requests.
+func String(v string) *string {
+	return &v
+}
+
+// Strings returns a pointer to the string slice, for the optional fields of update requests.
+// Calling it without arguments clears the field.
+func Strings(v ...string) *[]string {
+	if v == nil {
+		v = []string{}
+	}
+	return &v
+}
+
+// Int returns a pointer to the int value, for the optional fields of update requests.
+func Int(v int) *int {
+	return &v
+}
+
+// Bool returns a pointer to the bool value, for the optional fields of update requests.
+func Bool(v bool) *bool {
+	return &v
+}
+
+// Time returns a pointer to the time value, for the optional fields of update requests.
+func Time(v time.Time) *time.Time {
+	return &v
+}
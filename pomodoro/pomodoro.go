@@ -0,0 +1,300 @@
+// Package pomodoro runs pomodoro intervals tracked as toggl time entries.
+package pomodoro
+
+import (
+	"context"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+// Clock tells the time and waits. It is replaced in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// API is the part of the client used by the runner.
+type API interface {
+	GetRunningTimeEntry(ctx context.Context) (*toggl.TimeEntry, error)
+	GetTimeEntries(ctx context.Context, start, end time.Time) (*[]toggl.TimeEntry, error)
+	StartTimeEntryWithOptions(ctx context.Context, opts toggl.TimeEntryOptions) (*toggl.TimeEntry, error)
+	StopTimeEntryAt(ctx context.Context, id int, stop time.Time) (*toggl.TimeEntry, error)
+}
+
+// Config configures a Runner.
+type Config struct {
+	// Entry holds the description, project and tags of the work intervals.
+	// Its start, stop and duration are ignored.
+	Entry toggl.TimeEntryOptions
+	// Work is the length of the work intervals. Defaults to 25 minutes.
+	Work time.Duration
+	// ShortBreak and LongBreak are the lengths of the breaks. They default to 5 and 15 minutes.
+	ShortBreak time.Duration
+	LongBreak  time.Duration
+	// LongBreakEvery is the number of work intervals before a long break. Defaults to 4.
+	LongBreakEvery int
+	// Rounds is the number of work intervals run, 0 running until the context is done.
+	Rounds int
+	// Tag marks the intervals of the runner, so that they are resumed and the work
+	// intervals of the day are counted. Defaults to "pomodoro".
+	Tag string
+	// RecordBreaks tracks the breaks as time entries tagged with Tag and BreakTag,
+	// which defaults to "break".
+	RecordBreaks bool
+	BreakTag     string
+	// Clock defaults to the system clock.
+	Clock Clock
+}
+
+func (c Config) withDefaults() Config {
+	if c.Work == 0 {
+		c.Work = 25 * time.Minute
+	}
+	if c.ShortBreak == 0 {
+		c.ShortBreak = 5 * time.Minute
+	}
+	if c.LongBreak == 0 {
+		c.LongBreak = 15 * time.Minute
+	}
+	if c.LongBreakEvery == 0 {
+		c.LongBreakEvery = 4
+	}
+	if c.Tag == "" {
+		c.Tag = "pomodoro"
+	}
+	if c.BreakTag == "" {
+		c.BreakTag = "break"
+	}
+	if c.Clock == nil {
+		c.Clock = realClock{}
+	}
+	return c
+}
+
+// EventKind is the kind of an Event.
+type EventKind string
+
+// Events sent by a Runner.
+const (
+	EventWorkStarted   EventKind = "work_started"
+	EventWorkFinished  EventKind = "work_finished"
+	EventBreakStarted  EventKind = "break_started"
+	EventBreakFinished EventKind = "break_finished"
+)
+
+// Event reports the progress of a Runner.
+type Event struct {
+	Kind  EventKind
+	Round int
+	// TimeEntry is the time entry of the interval, nil for unrecorded breaks.
+	TimeEntry *toggl.TimeEntry
+	// Start and End bound the interval.
+	Start time.Time
+	End   time.Time
+	// Resumed is set on the start of an interval found running.
+	Resumed bool
+}
+
+// Runner runs pomodoro intervals, starting a time entry for every work
+// interval and stopping it when the interval ends.
+type Runner struct {
+	api    API
+	config Config
+	events chan Event
+}
+
+// NewRunner builds a runner.
+func NewRunner(api API, config Config) *Runner {
+	return &Runner{
+		api:    api,
+		config: config.withDefaults(),
+		events: make(chan Event, 16),
+	}
+}
+
+// Events returns the channel the events are sent to. It is closed when Run returns.
+// Run blocks when the channel is full, so it must be drained.
+func (r *Runner) Events() <-chan Event {
+	return r.events
+}
+
+// Run runs the intervals until the configured rounds are done or the context
+// is done. An interval of a previous run still running, as tagged time
+// entries, is resumed, and the rounds continue from the work intervals
+// finished today. When the context is done, the running time entry is left
+// running so that the next run resumes it.
+func (r *Runner) Run(ctx context.Context) error {
+	defer close(r.events)
+
+	running, err := r.api.GetRunningTimeEntry(ctx)
+	if err != nil {
+		return err
+	}
+
+	resumeBreak := running != nil && r.config.RecordBreaks && r.isBreak(running)
+	if running != nil && !r.isWork(running) && !resumeBreak {
+		running = nil
+	}
+
+	done, err := r.workDone(ctx)
+	if err != nil {
+		return err
+	}
+	round := done + 1
+	if resumeBreak && done > 0 {
+		// The break follows the last finished work interval.
+		round = done
+	}
+
+	var start time.Time
+	for ran := 1; ; round, ran = round+1, ran+1 {
+		if !resumeBreak {
+			end, err := r.interval(ctx, round, false, start, running)
+			if err != nil {
+				return err
+			}
+			start, running = end, nil
+
+			if r.config.Rounds > 0 && ran >= r.config.Rounds {
+				return nil
+			}
+		}
+		resumeBreak = false
+
+		end, err := r.interval(ctx, round, true, start, running)
+		if err != nil {
+			return err
+		}
+		start, running = end, nil
+	}
+}
+
+// workDone counts the work intervals finished today.
+func (r *Runner) workDone(ctx context.Context) (int, error) {
+	now := r.config.Clock.Now()
+	y, m, d := now.Date()
+	timeEntries, err := r.api.GetTimeEntries(ctx, time.Date(y, m, d, 0, 0, 0, 0, now.Location()), now)
+	if err != nil {
+		return 0, err
+	}
+
+	done := 0
+	for i := range *timeEntries {
+		if te := &(*timeEntries)[i]; !te.IsRunning() && r.isWork(te) {
+			done++
+		}
+	}
+	return done, nil
+}
+
+// isWork tells whether the time entry is a work interval of the runner.
+func (r *Runner) isWork(timeEntry *toggl.TimeEntry) bool {
+	return hasTag(timeEntry, r.config.Tag) && !hasTag(timeEntry, r.config.BreakTag)
+}
+
+// isBreak tells whether the time entry is a break of the runner, rather than
+// one tracked by the user.
+func (r *Runner) isBreak(timeEntry *toggl.TimeEntry) bool {
+	return hasTag(timeEntry, r.config.Tag) && hasTag(timeEntry, r.config.BreakTag)
+}
+
+// interval runs a work interval or a break from start, now when zero, or
+// resumes the running time entry. It returns when the next interval starts.
+func (r *Runner) interval(ctx context.Context, round int, isBreak bool, start time.Time, running *toggl.TimeEntry) (time.Time, error) {
+	length := r.config.Work
+	started, finished := EventWorkStarted, EventWorkFinished
+	if isBreak {
+		length = r.config.ShortBreak
+		if round%r.config.LongBreakEvery == 0 {
+			length = r.config.LongBreak
+		}
+		started, finished = EventBreakStarted, EventBreakFinished
+	}
+
+	timeEntry := running
+	if timeEntry == nil && (!isBreak || r.config.RecordBreaks) {
+		var err error
+		timeEntry, err = r.api.StartTimeEntryWithOptions(ctx, r.options(isBreak, start))
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	if timeEntry != nil {
+		start = timeEntry.Start
+	}
+	if start.IsZero() {
+		start = r.config.Clock.Now()
+	}
+	end := start.Add(length)
+
+	// Time entries found running past their end are stopped at their end,
+	// and the next interval starts now.
+	next := end
+	if now := r.config.Clock.Now(); running != nil && now.After(end) {
+		next = now
+	}
+
+	if err := r.send(ctx, Event{Kind: started, Round: round, TimeEntry: timeEntry, Start: start, End: end, Resumed: running != nil}); err != nil {
+		return time.Time{}, err
+	}
+
+	if wait := end.Sub(r.config.Clock.Now()); wait > 0 {
+		select {
+		case <-r.config.Clock.After(wait):
+		case <-ctx.Done():
+			return time.Time{}, ctx.Err()
+		}
+	}
+
+	if timeEntry != nil {
+		stopped, err := r.api.StopTimeEntryAt(ctx, timeEntry.ID, end)
+		if err != nil {
+			return time.Time{}, err
+		}
+		timeEntry = stopped
+	}
+
+	return next, r.send(ctx, Event{Kind: finished, Round: round, TimeEntry: timeEntry, Start: start, End: end})
+}
+
+func (r *Runner) options(isBreak bool, start time.Time) toggl.TimeEntryOptions {
+	opts := r.config.Entry
+	opts.Start = start
+	opts.Stop = time.Time{}
+	opts.Duration = 0
+
+	if isBreak {
+		// Breaks stay in the project of the work, but are not billed.
+		opts.Description = "Break"
+		opts.Tags = []string{r.config.BreakTag, r.config.Tag}
+		opts.Billable = false
+		return opts
+	}
+	opts.Tags = append(append([]string{}, opts.Tags...), r.config.Tag)
+
+	return opts
+}
+
+func (r *Runner) send(ctx context.Context, e Event) error {
+	select {
+	case r.events <- e:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func hasTag(timeEntry *toggl.TimeEntry, tag string) bool {
+	for _, t := range timeEntry.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package pomodoro
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+// fakeClock jumps forward instead of waiting.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+type fakeAPI struct {
+	clock   *fakeClock
+	running *toggl.TimeEntry
+	entries map[int]*toggl.TimeEntry
+	started []toggl.TimeEntryOptions
+}
+
+func (f *fakeAPI) GetRunningTimeEntry(ctx context.Context) (*toggl.TimeEntry, error) {
+	return f.running, nil
+}
+
+func (f *fakeAPI) GetTimeEntries(ctx context.Context, start, end time.Time) (*[]toggl.TimeEntry, error) {
+	timeEntries := []toggl.TimeEntry{}
+	for _, te := range f.entries {
+		if !te.Start.Before(start) && te.Start.Before(end) {
+			timeEntries = append(timeEntries, *te)
+		}
+	}
+	return &timeEntries, nil
+}
+
+func (f *fakeAPI) StartTimeEntryWithOptions(ctx context.Context, opts toggl.TimeEntryOptions) (*toggl.TimeEntry, error) {
+	f.started = append(f.started, opts)
+	start := opts.Start
+	if start.IsZero() {
+		start = f.clock.Now()
+	}
+	te := &toggl.TimeEntry{ID: len(f.entries) + 1, Description: opts.Description, Tags: opts.Tags, Start: start, Duration: -int(start.Unix())}
+	f.entries[te.ID] = te
+	return te, nil
+}
+
+func (f *fakeAPI) StopTimeEntryAt(ctx context.Context, id int, stop time.Time) (*toggl.TimeEntry, error) {
+	te := f.entries[id]
+	te.Stop = stop
+	te.Duration = int(stop.Sub(te.Start).Seconds())
+	return te, nil
+}
+
+func collect(r *Runner) []string {
+	events := []string{}
+	for e := range r.Events() {
+		events = append(events, string(e.Kind)+" "+e.Start.Format("15:04")+"-"+e.End.Format("15:04"))
+	}
+	return events
+}
+
+func TestRunner(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2018, 4, 12, 9, 0, 0, 0, time.UTC)}
+	api := &fakeAPI{clock: clock, entries: map[int]*toggl.TimeEntry{}}
+	r := NewRunner(api, Config{
+		Entry:          toggl.TimeEntryOptions{Pid: 10, Description: "coding", Tags: []string{"dev"}, Billable: true},
+		LongBreakEvery: 2,
+		Rounds:         3,
+		RecordBreaks:   true,
+		Clock:          clock,
+	})
+
+	done := make(chan error)
+	go func() {
+		done <- r.Run(context.Background())
+	}()
+	events := collect(r)
+	if err := <-done; err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	expected := []string{
+		"work_started 09:00-09:25",
+		"work_finished 09:00-09:25",
+		"break_started 09:25-09:30",
+		"break_finished 09:25-09:30",
+		"work_started 09:30-09:55",
+		"work_finished 09:30-09:55",
+		"break_started 09:55-10:10",
+		"break_finished 09:55-10:10",
+		"work_started 10:10-10:35",
+		"work_finished 10:10-10:35",
+	}
+	if !reflect.DeepEqual(expected, events) {
+		t.Fatalf("Expected events %q, got %q", expected, events)
+	}
+
+	if len(api.entries) != 5 {
+		t.Fatalf("Expected 5 time entries, got %d", len(api.entries))
+	}
+	for _, te := range api.entries {
+		if te.IsRunning() {
+			t.Fatalf("Expected the time entries to be stopped, got %+v", te)
+		}
+	}
+	if !reflect.DeepEqual([]string{"dev", "pomodoro"}, api.started[0].Tags) || !api.started[0].Billable {
+		t.Fatalf("Unexpected work entry %+v", api.started[0])
+	}
+	if !reflect.DeepEqual([]string{"break", "pomodoro"}, api.started[1].Tags) || api.started[1].Billable {
+		t.Fatalf("Unexpected break entry %+v", api.started[1])
+	}
+}
+
+func TestRunnerResume(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2018, 4, 12, 9, 10, 0, 0, time.UTC)}
+	start := time.Date(2018, 4, 12, 9, 0, 0, 0, time.UTC)
+	running := &toggl.TimeEntry{ID: 1, Tags: []string{"pomodoro"}, Start: start, Duration: -int(start.Unix())}
+	api := &fakeAPI{clock: clock, running: running, entries: map[int]*toggl.TimeEntry{1: running}}
+	r := NewRunner(api, Config{Rounds: 1, Clock: clock})
+
+	done := make(chan error)
+	go func() {
+		done <- r.Run(context.Background())
+	}()
+	events := collect(r)
+	if err := <-done; err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	expected := []string{"work_started 09:00-09:25", "work_finished 09:00-09:25"}
+	if !reflect.DeepEqual(expected, events) || len(api.started) != 0 || running.Duration != 25*60 {
+		t.Fatalf("Expected the running entry to be resumed, got events %q and entries %+v", events, api.entries)
+	}
+}
+
+func TestRunnerResumeRound(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2018, 4, 12, 9, 10, 0, 0, time.UTC)}
+	at := func(hour, min int) time.Time {
+		return time.Date(2018, 4, 12, hour, min, 0, 0, time.UTC)
+	}
+	work := func(id int, start time.Time) *toggl.TimeEntry {
+		return &toggl.TimeEntry{ID: id, Tags: []string{"pomodoro"}, Start: start, Stop: start.Add(25 * time.Minute), Duration: 25 * 60}
+	}
+	running := &toggl.TimeEntry{ID: 5, Tags: []string{"pomodoro"}, Start: at(9, 0), Duration: -int(at(9, 0).Unix())}
+	api := &fakeAPI{clock: clock, running: running, entries: map[int]*toggl.TimeEntry{
+		// Yesterday's work interval is not counted.
+		1: work(1, at(9, 0).AddDate(0, 0, -1)),
+		2: work(2, at(7, 0)),
+		3: work(3, at(7, 30)),
+		4: work(4, at(8, 0)),
+		5: running,
+	}}
+	r := NewRunner(api, Config{Rounds: 2, Clock: clock})
+
+	done := make(chan error)
+	go func() {
+		done <- r.Run(context.Background())
+	}()
+	events := collect(r)
+	if err := <-done; err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	// The resumed interval is the fourth of the day, followed by a long break.
+	expected := []string{
+		"work_started 09:00-09:25",
+		"work_finished 09:00-09:25",
+		"break_started 09:25-09:40",
+		"break_finished 09:25-09:40",
+		"work_started 09:40-10:05",
+		"work_finished 09:40-10:05",
+	}
+	if !reflect.DeepEqual(expected, events) {
+		t.Fatalf("Expected events %q, got %q", expected, events)
+	}
+}
+
+func TestRunnerUserBreak(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2018, 4, 12, 9, 10, 0, 0, time.UTC)}
+	start := time.Date(2018, 4, 12, 9, 0, 0, 0, time.UTC)
+	running := &toggl.TimeEntry{ID: 1, Tags: []string{"break"}, Start: start, Duration: -int(start.Unix())}
+	api := &fakeAPI{clock: clock, running: running, entries: map[int]*toggl.TimeEntry{1: running}}
+	r := NewRunner(api, Config{Entry: toggl.TimeEntryOptions{Wid: 1}, Rounds: 1, RecordBreaks: true, Clock: clock})
+
+	done := make(chan error)
+	go func() {
+		done <- r.Run(context.Background())
+	}()
+	events := collect(r)
+	if err := <-done; err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	// A break not started by the runner is not resumed.
+	expected := []string{"work_started 09:10-09:35", "work_finished 09:10-09:35"}
+	if !reflect.DeepEqual(expected, events) || len(api.started) != 1 || !running.Stop.IsZero() {
+		t.Fatalf("Expected a new work interval, got events %q and entries %+v", events, api.entries)
+	}
+}
+
+func TestRunnerCancel(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2018, 4, 12, 9, 0, 0, 0, time.UTC)}
+	api := &fakeAPI{clock: clock, entries: map[int]*toggl.TimeEntry{}}
+	r := NewRunner(api, Config{Entry: toggl.TimeEntryOptions{Wid: 1}, Clock: clock})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- r.Run(ctx)
+	}()
+	<-r.Events()
+	cancel()
+	for range r.Events() {
+	}
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Expected the context error, got %v", err)
+	}
+}
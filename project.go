@@ -12,6 +12,7 @@ import (
 type Project struct {
 	ID            int       `json:"id"`
 	Wid           int       `json:"wid"`
+	Cid           int       `json:"cid"`
 	Name          string    `json:"name"`
 	Billable      bool      `json:"billable"`
 	IsPrivate     bool      `json:"is_private"`
@@ -23,6 +24,8 @@ type Project struct {
 	AutoEstimates bool      `json:"auto_estimates"`
 	ActualHours   int       `json:"actual_hours"`
 	HexColor      string    `json:"hex_color"`
+	// ServerDeletedAt is set on projects deleted since the last sync.
+	ServerDeletedAt *time.Time `json:"server_deleted_at,omitempty"`
 }
 
 type projectResponse struct {
@@ -37,6 +40,17 @@ type projectRequest struct {
 	Cid        int    `json:"cid"`
 }
 
+// ProjectUpdate is used to partially update a project. Only the non-nil fields are sent.
+type ProjectUpdate struct {
+	Name          *string `json:"name,omitempty"`
+	Cid           *int    `json:"cid,omitempty"`
+	Active        *bool   `json:"active,omitempty"`
+	IsPrivate     *bool   `json:"is_private,omitempty"`
+	Billable      *bool   `json:"billable,omitempty"`
+	AutoEstimates *bool   `json:"auto_estimates,omitempty"`
+	Color         *string `json:"color,omitempty"`
+}
+
 // GetProject will retrive the complete project information.
 func (c *Client) GetProject(ctx context.Context, id int) (*Project, error) {
 	spath := fmt.Sprintf("v8/projects/%d", id)
@@ -79,7 +93,8 @@ func (c *Client) CreateProject(ctx context.Context, name string, workspaceID int
 	return &response.Data, nil
 }
 
-// UpdateProject updates the project based in the given configuration.
+// UpdateProject updates every field of the project based in the given configuration.
+// Use PatchProject to update some fields only.
 func (c *Client) UpdateProject(ctx context.Context, projectID int, name string, workspaceID int, templateID int, isPrivate bool, clientID int) (*Project, error) {
 	spath := fmt.Sprintf("v8/projects/%d", projectID)
 	response := &projectResponse{}
@@ -108,6 +123,29 @@ func (c *Client) UpdateProject(ctx context.Context, projectID int, name string,
 	return &response.Data, nil
 }
 
+// PatchProject updates the fields of the project set in the update.
+func (c *Client) PatchProject(ctx context.Context, id int, update ProjectUpdate) (*Project, error) {
+	spath := fmt.Sprintf("v8/projects/%d", id)
+	response := &projectResponse{}
+
+	params := struct {
+		Project ProjectUpdate `json:"project"`
+	}{
+		Project: update,
+	}
+	j, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.put(ctx, spath, bytes.NewBuffer(j), response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
 // DeleteProject will delete the project.
 func (c *Client) DeleteProject(ctx context.Context, id int) error {
 	spath := fmt.Sprintf("v8/projects/%d", id)
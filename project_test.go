@@ -210,3 +210,52 @@ func TestGetProjectUsers(t *testing.T) {
 		t.Fatal(errors.New("Response is incorrect"))
 	}
 }
+
+func TestPatchProject(t *testing.T) {
+	expectedURL := "/api/v8/projects/111358164"
+	expectedBody := `{"project":{"name":"test project","active":false,"color":"3"}}`
+	expected := getTestProject()
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if !strings.HasPrefix(req.URL.Path, expectedURL) {
+			return nil, fmt.Errorf("Expected URL '%s', got %s", expectedURL, req.URL.Path)
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if string(body) != expectedBody {
+			return nil, fmt.Errorf("Expected body '%s', got %s", expectedBody, body)
+		}
+
+		b, err := json.Marshal(struct {
+			Data Project `json:"data"`
+		}{
+			Data: getTestProject(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+
+	})
+	api := New("test", OptionHTTPClient(client))
+
+	project, err := api.PatchProject(context.Background(), 111358164, ProjectUpdate{
+		Name:   String("test project"),
+		Active: Bool(false),
+		Color:  String("3"),
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if !reflect.DeepEqual(expected, *project) {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+}
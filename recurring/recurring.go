@@ -0,0 +1,296 @@
+// Package recurring creates the time entries logged on a schedule, like daily standups.
+package recurring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+const (
+	dateLayout  = "2006-01-02"
+	clockLayout = "15:04"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Template is a named time entry created on a weekly schedule.
+type Template struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Wid         int      `json:"wid"`
+	Pid         int      `json:"pid"`
+	Tags        []string `json:"tags"`
+	Billable    bool     `json:"billable"`
+	// Start is the local time of day the entry starts at, as "15:04".
+	Start string `json:"start"`
+	// Duration is the length of the entry, as parsed by time.ParseDuration.
+	Duration string `json:"duration"`
+	// Weekdays the entry is created on, as "mon" to "sun". Every day when empty.
+	Weekdays []string `json:"weekdays"`
+
+	hour     int
+	minute   int
+	duration time.Duration
+	days     map[time.Weekday]bool
+}
+
+func (t *Template) parse() error {
+	if t.Name == "" {
+		return fmt.Errorf("template without name")
+	}
+
+	clock, err := time.Parse(clockLayout, t.Start)
+	if err != nil {
+		return fmt.Errorf("template %s: invalid start %q", t.Name, t.Start)
+	}
+	t.hour, t.minute = clock.Hour(), clock.Minute()
+
+	t.duration, err = time.ParseDuration(t.Duration)
+	if err != nil || t.duration <= 0 {
+		return fmt.Errorf("template %s: invalid duration %q", t.Name, t.Duration)
+	}
+
+	t.days = map[time.Weekday]bool{}
+	for _, name := range t.Weekdays {
+		d, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return fmt.Errorf("template %s: invalid weekday %q", t.Name, name)
+		}
+		t.days[d] = true
+	}
+
+	return nil
+}
+
+// startOn returns the start of the entry on the day, built from the calendar
+// so that it keeps its clock time on daylight saving changes.
+func (t *Template) startOn(day time.Time) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, t.hour, t.minute, 0, 0, day.Location())
+}
+
+func (t *Template) scheduled(day time.Time) bool {
+	return len(t.days) == 0 || t.days[day.Weekday()]
+}
+
+// matches reports whether the time entry is an occurrence of the template.
+func (t *Template) matches(te toggl.TimeEntry) bool {
+	return te.Description == t.Description && te.Pid == t.Pid
+}
+
+// Config holds the templates and the holidays no entry is created on.
+type Config struct {
+	Templates []Template `json:"templates"`
+	// Holidays are dates as "2006-01-02".
+	Holidays []string `json:"holidays"`
+}
+
+// Load reads a JSON configuration and validates it.
+func Load(r io.Reader) (*Config, error) {
+	c := &Config{}
+	if err := json.NewDecoder(r).Decode(c); err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for i := range c.Templates {
+		if err := c.Templates[i].parse(); err != nil {
+			return nil, err
+		}
+		if names[c.Templates[i].Name] {
+			return nil, fmt.Errorf("duplicate template %s", c.Templates[i].Name)
+		}
+		names[c.Templates[i].Name] = true
+	}
+	for _, h := range c.Holidays {
+		if _, err := time.Parse(dateLayout, h); err != nil {
+			return nil, fmt.Errorf("invalid holiday %q", h)
+		}
+	}
+
+	return c, nil
+}
+
+// LoadFile reads the JSON configuration stored at path.
+func LoadFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// TimeEntryAPI is the part of the client used to create the time entries.
+type TimeEntryAPI interface {
+	GetTimeEntries(ctx context.Context, start, end time.Time) (*[]toggl.TimeEntry, error)
+	CreateTimeEntryWithOptions(ctx context.Context, opts toggl.TimeEntryOptions) (*toggl.TimeEntry, error)
+}
+
+// Options configures Materialize.
+type Options struct {
+	// Location of the days and start times. Defaults to time.Local.
+	Location *time.Location
+	// Templates restricts the templates created, by name. All of them when empty.
+	Templates []string
+	// Holidays are dates no entry is created on, in addition to the configured ones.
+	Holidays []time.Time
+	// CreatedWith is sent as the created_with of the entries.
+	CreatedWith string
+	// DryRun plans the entries without creating them.
+	DryRun bool
+}
+
+// Occurrence is a time entry of a template on a day.
+type Occurrence struct {
+	Template string
+	Day      time.Time
+	Options  toggl.TimeEntryOptions
+	// TimeEntry is the created time entry, nil on dry runs and skipped occurrences.
+	TimeEntry *toggl.TimeEntry
+	// Skipped tells why the occurrence was not created.
+	Skipped string
+}
+
+// Report lists the occurrences of the templates within the range.
+type Report struct {
+	Occurrences []Occurrence
+}
+
+// Created returns the occurrences created or, on dry runs, to be created.
+func (r *Report) Created() []Occurrence {
+	created := []Occurrence{}
+	for _, o := range r.Occurrences {
+		if o.Skipped == "" {
+			created = append(created, o)
+		}
+	}
+	return created
+}
+
+// Materialize creates the time entries of the templates scheduled on the days
+// from start to end included. Days already having a time entry with the
+// description and project of a template, and holidays, are skipped.
+func Materialize(ctx context.Context, api TimeEntryAPI, config *Config, start, end time.Time, options *Options) (*Report, error) {
+	opts := Options{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+
+	templates := []*Template{}
+	for i := range config.Templates {
+		t := &config.Templates[i]
+		if len(opts.Templates) == 0 || contains(opts.Templates, t.Name) {
+			templates = append(templates, t)
+		}
+	}
+	for _, name := range opts.Templates {
+		if !containsTemplate(config.Templates, name) {
+			return nil, fmt.Errorf("unknown template %s", name)
+		}
+	}
+
+	holidays := map[string]bool{}
+	for _, h := range config.Holidays {
+		holidays[h] = true
+	}
+	for _, h := range opts.Holidays {
+		holidays[h.Format(dateLayout)] = true
+	}
+
+	first := midnight(start.In(opts.Location))
+	last := midnight(end.In(opts.Location))
+	existing, err := api.GetTimeEntries(ctx, first, last.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Occurrences: []Occurrence{}}
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		for _, t := range templates {
+			if !t.scheduled(day) {
+				continue
+			}
+
+			o := Occurrence{
+				Template: t.Name,
+				Day:      day,
+				Options: toggl.TimeEntryOptions{
+					Description: t.Description,
+					Tags:        t.Tags,
+					Wid:         t.Wid,
+					Pid:         t.Pid,
+					Billable:    t.Billable,
+					Start:       t.startOn(day),
+					Duration:    t.duration,
+					CreatedWith: opts.CreatedWith,
+				},
+			}
+			switch {
+			case holidays[day.Format(dateLayout)]:
+				o.Skipped = "holiday"
+			case hasOccurrence(*existing, t, day, opts.Location):
+				o.Skipped = "already tracked"
+			case !opts.DryRun:
+				o.TimeEntry, err = api.CreateTimeEntryWithOptions(ctx, o.Options)
+				if err != nil {
+					return report, fmt.Errorf("template %s on %s: %s", t.Name, day.Format(dateLayout), err)
+				}
+			}
+			report.Occurrences = append(report.Occurrences, o)
+		}
+	}
+
+	return report, nil
+}
+
+func hasOccurrence(timeEntries []toggl.TimeEntry, t *Template, day time.Time, loc *time.Location) bool {
+	for _, te := range timeEntries {
+		if t.matches(te) && midnight(te.Start.In(loc)).Equal(day) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func containsTemplate(templates []Template, name string) bool {
+	for _, t := range templates {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
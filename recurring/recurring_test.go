@@ -0,0 +1,152 @@
+package recurring
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+const testConfig = `{
+	"templates": [
+		{"name": "standup", "description": "Standup", "pid": 10, "tags": ["meeting"], "start": "09:30", "duration": "15m", "weekdays": ["mon", "tue", "wed", "thu", "fri"]},
+		{"name": "oncall", "description": "On-call", "wid": 1, "start": "18:00", "duration": "1h", "weekdays": ["Sat"]}
+	],
+	"holidays": ["2018-04-13"]
+}`
+
+type fakeAPI struct {
+	entries []toggl.TimeEntry
+	created []toggl.TimeEntryOptions
+}
+
+func (f *fakeAPI) GetTimeEntries(ctx context.Context, start, end time.Time) (*[]toggl.TimeEntry, error) {
+	return &f.entries, nil
+}
+
+func (f *fakeAPI) CreateTimeEntryWithOptions(ctx context.Context, opts toggl.TimeEntryOptions) (*toggl.TimeEntry, error) {
+	f.created = append(f.created, opts)
+	return &toggl.TimeEntry{ID: len(f.created), Description: opts.Description, Start: opts.Start}, nil
+}
+
+func TestMaterialize(t *testing.T) {
+	config, err := Load(strings.NewReader(testConfig))
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	loc := time.FixedZone("JST", 9*3600)
+	day := func(d int) time.Time {
+		return time.Date(2018, 4, d, 0, 0, 0, 0, loc)
+	}
+	api := &fakeAPI{entries: []toggl.TimeEntry{
+		// Standup already tracked on wednesday.
+		{ID: 1, Pid: 10, Description: "Standup", Start: day(11).Add(9 * time.Hour)},
+	}}
+
+	// From wednesday 11th to monday 16th, the 16th being a holiday as well.
+	report, err := Materialize(context.Background(), api, config, day(11), day(16).Add(time.Hour), &Options{
+		Location: loc,
+		Holidays: []time.Time{day(16)},
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	got := []string{}
+	for _, o := range report.Occurrences {
+		got = append(got, o.Template+" "+o.Day.Format("01-02")+" "+o.Skipped)
+	}
+	expected := []string{
+		"standup 04-11 already tracked",
+		"standup 04-12 ",
+		"standup 04-13 holiday",
+		"oncall 04-14 ",
+		"standup 04-16 holiday",
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected occurrences %q, got %q", expected, got)
+	}
+
+	expectedCreated := []toggl.TimeEntryOptions{
+		{Description: "Standup", Pid: 10, Tags: []string{"meeting"}, Start: day(12).Add(9*time.Hour + 30*time.Minute), Duration: 15 * time.Minute},
+		{Description: "On-call", Wid: 1, Start: day(14).Add(18 * time.Hour), Duration: time.Hour},
+	}
+	if !reflect.DeepEqual(expectedCreated, api.created) {
+		t.Fatalf("Expected created entries %+v, got %+v", expectedCreated, api.created)
+	}
+	if len(report.Created()) != 2 || report.Created()[0].TimeEntry == nil {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+
+	// Dry runs and template selection.
+	api.created = nil
+	report, err = Materialize(context.Background(), api, config, day(12), day(14), &Options{Location: loc, Templates: []string{"oncall"}, DryRun: true})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if len(report.Created()) != 1 || report.Created()[0].TimeEntry != nil || len(api.created) != 0 {
+		t.Fatalf("Unexpected dry run %+v", report)
+	}
+
+	if _, err := Materialize(context.Background(), api, config, day(12), day(14), &Options{Templates: []string{"unknown"}}); err == nil {
+		t.Fatal(errors.New("Expected an error for an unknown template"))
+	}
+}
+
+func TestMaterializeDaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	config, err := Load(strings.NewReader(`{"templates": [{"name": "standup", "pid": 10, "start": "09:00", "duration": "15m"}]}`))
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	// Clocks moved forward on 2018-03-25 and back on 2018-10-28 in Berlin.
+	api := &fakeAPI{}
+	for _, day := range []time.Time{
+		time.Date(2018, 3, 25, 0, 0, 0, 0, berlin),
+		time.Date(2018, 10, 28, 0, 0, 0, 0, berlin),
+	} {
+		if _, err := Materialize(context.Background(), api, config, day, day, &Options{Location: berlin}); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+	}
+
+	for _, created := range api.created {
+		if start := created.Start.In(berlin); start.Hour() != 9 || start.Minute() != 0 {
+			t.Fatalf("Expected entries starting at 09:00, got %s", start)
+		}
+	}
+	if len(api.created) != 2 {
+		t.Fatalf("Expected 2 created entries, got %d", len(api.created))
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []string{
+		`{"templates": [{"description": "x", "start": "09:00", "duration": "1h"}]}`,
+		`{"templates": [{"name": "a", "start": "9am", "duration": "1h"}]}`,
+		`{"templates": [{"name": "a", "start": "09:00", "duration": "-1h"}]}`,
+		`{"templates": [{"name": "a", "start": "09:00", "duration": "1h", "weekdays": ["monday"]}]}`,
+		`{"templates": [{"name": "a", "start": "09:00", "duration": "1h"}, {"name": "a", "start": "09:00", "duration": "1h"}]}`,
+		`{"holidays": ["13/04/2018"]}`,
+	}
+
+	for _, test := range tests {
+		if _, err := Load(strings.NewReader(test)); err == nil {
+			t.Errorf("Expected an error loading %s", test)
+		}
+	}
+}
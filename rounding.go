@@ -0,0 +1,64 @@
+package toggl
+
+import "time"
+
+// Rounding directions of Workspace.Rounding.
+const (
+	RoundDown    = -1
+	RoundNearest = 0
+	RoundUp      = 1
+)
+
+// RoundingMode tells whether a rounding applies to every time entry or to their total.
+type RoundingMode int
+
+// Rounding modes of Workspace.RoundTimeEntries.
+const (
+	RoundPerEntry RoundingMode = iota
+	RoundTotal
+)
+
+// RoundDuration rounds d to a multiple of the workspace RoundingMinutes, down,
+// to the nearest (halves going up) or up depending on Rounding. Workspaces
+// without rounding minutes leave d as is.
+func (w Workspace) RoundDuration(d time.Duration) time.Duration {
+	if w.RoundingMinutes <= 0 {
+		return d
+	}
+	step := time.Duration(w.RoundingMinutes) * time.Minute
+	floor := d - d%step
+	if d%step < 0 {
+		floor -= step
+	}
+
+	switch {
+	case w.Rounding < RoundNearest:
+		return floor
+	case w.Rounding > RoundNearest:
+		if floor == d {
+			return d
+		}
+		return floor + step
+	}
+	if d-floor >= step/2 {
+		return floor + step
+	}
+	return floor
+}
+
+// RoundTimeEntries sums the tracked time of the time entries, running entries
+// ending at now, rounding either every entry or the total.
+func (w Workspace) RoundTimeEntries(timeEntries []TimeEntry, mode RoundingMode, now time.Time) time.Duration {
+	var total time.Duration
+	for _, te := range timeEntries {
+		if mode == RoundPerEntry {
+			total += w.RoundDuration(te.Elapsed(now))
+		} else {
+			total += te.Elapsed(now)
+		}
+	}
+	if mode == RoundTotal {
+		total = w.RoundDuration(total)
+	}
+	return total
+}
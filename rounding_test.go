@@ -0,0 +1,64 @@
+package toggl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRoundDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		rounding int
+		minutes  int
+		d        time.Duration
+		expected time.Duration
+	}{
+		{"no rounding", RoundUp, 0, 7 * time.Minute, 7 * time.Minute},
+		{"up", RoundUp, 15, 16 * time.Minute, 30 * time.Minute},
+		{"up exact", RoundUp, 15, 30 * time.Minute, 30 * time.Minute},
+		{"up seconds", RoundUp, 1, 61 * time.Second, 2 * time.Minute},
+		{"down", RoundDown, 15, 29 * time.Minute, 15 * time.Minute},
+		{"down below step", RoundDown, 15, 14 * time.Minute, 0},
+		{"nearest below half", RoundNearest, 15, 7 * time.Minute, 0},
+		{"nearest half", RoundNearest, 15, 7*time.Minute + 30*time.Second, 15 * time.Minute},
+		{"nearest above half", RoundNearest, 60, 95 * time.Minute, 2 * time.Hour},
+		{"zero", RoundUp, 15, 0, 0},
+	}
+
+	for _, test := range tests {
+		w := Workspace{Rounding: test.rounding, RoundingMinutes: test.minutes}
+		if got := w.RoundDuration(test.d); got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestRoundTimeEntries(t *testing.T) {
+	start := time.Date(2018, 4, 12, 9, 0, 0, 0, time.UTC)
+	timeEntries := []TimeEntry{
+		{Start: start, Duration: 10 * 60},
+		{Start: start, Duration: 10 * 60},
+		{Start: start, Duration: -int(start.Unix())},
+	}
+	now := start.Add(10 * time.Minute)
+
+	tests := []struct {
+		rounding int
+		mode     RoundingMode
+		expected time.Duration
+	}{
+		{RoundUp, RoundPerEntry, 45 * time.Minute},
+		{RoundUp, RoundTotal, 30 * time.Minute},
+		{RoundDown, RoundPerEntry, 0},
+		{RoundDown, RoundTotal, 30 * time.Minute},
+		{RoundNearest, RoundPerEntry, 45 * time.Minute},
+		{RoundNearest, RoundTotal, 30 * time.Minute},
+	}
+
+	for _, test := range tests {
+		w := Workspace{Rounding: test.rounding, RoundingMinutes: 15}
+		if got := w.RoundTimeEntries(timeEntries, test.mode, now); got != test.expected {
+			t.Errorf("rounding %d mode %d: expected %s, got %s", test.rounding, test.mode, test.expected, got)
+		}
+	}
+}
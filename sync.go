@@ -0,0 +1,219 @@
+package toggl
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// SyncKind is the kind of object a ChangeEvent is about.
+type SyncKind string
+
+// Kinds of objects mirrored by a Syncer.
+const (
+	SyncWorkspace SyncKind = "workspace"
+	SyncProject   SyncKind = "project"
+	SyncTag       SyncKind = "tag"
+	SyncTimeEntry SyncKind = "time_entry"
+)
+
+// SyncAction is the change a ChangeEvent reports.
+type SyncAction string
+
+// Changes reported by a Syncer.
+const (
+	SyncUpsert SyncAction = "upsert"
+	SyncDelete SyncAction = "delete"
+)
+
+// ChangeEvent reports an object created, updated or deleted on the server.
+// Only the field matching the kind is set, and none is set for deletions.
+type ChangeEvent struct {
+	Kind      SyncKind
+	Action    SyncAction
+	ID        int
+	Workspace *Workspace
+	Project   *Project
+	Tag       *Tag
+	TimeEntry *TimeEntry
+}
+
+// SyncSink receives the changes found by a Syncer.
+type SyncSink interface {
+	// Apply stores the events. The sync cursor only moves forward when it succeeds.
+	Apply(ctx context.Context, events []ChangeEvent) error
+}
+
+// SyncState is the cursor of a Syncer. Persist it between syncs to only fetch changes.
+type SyncState struct {
+	// Since is the server time of the last sync, 0 before the first sync.
+	Since int64 `json:"since"`
+	// Known holds the IDs mirrored so far, used to detect deletions on full syncs.
+	Known map[SyncKind]map[int]bool `json:"known"`
+	// Starts holds the Unix start times of the mirrored time entries. Full syncs
+	// only see the recent time entries, so the older ones are not reported as deleted.
+	Starts map[int]int64 `json:"starts,omitempty"`
+}
+
+// Syncer mirrors the workspaces, projects, tags and time entries of the token owner,
+// fetching only the changes since the previous sync.
+type Syncer struct {
+	client *Client
+	sink   SyncSink
+	state  SyncState
+}
+
+// NewSyncer builds a syncer resuming from the given state.
+func NewSyncer(client *Client, sink SyncSink, state SyncState) *Syncer {
+	if state.Known == nil {
+		state.Known = map[SyncKind]map[int]bool{}
+	}
+	for _, kind := range []SyncKind{SyncWorkspace, SyncProject, SyncTag, SyncTimeEntry} {
+		if state.Known[kind] == nil {
+			state.Known[kind] = map[int]bool{}
+		}
+	}
+	if state.Starts == nil {
+		state.Starts = map[int]int64{}
+	}
+
+	return &Syncer{
+		client: client,
+		sink:   sink,
+		state:  state,
+	}
+}
+
+// State returns the current cursor of the syncer.
+func (s *Syncer) State() SyncState {
+	return s.state
+}
+
+// Sync fetches the changes since the previous sync and sends them to the sink.
+// Deletions are detected through the server_deleted_at field of the changed objects.
+// It returns the number of events sent.
+func (s *Syncer) Sync(ctx context.Context) (int, error) {
+	return s.sync(ctx, s.state.Since)
+}
+
+// Resync fetches every object and sends them to the sink. Objects known from the
+// previous syncs and missing on the server are reported as deleted, which also
+// detects deleted workspaces. The server only returns the recent time entries:
+// missing time entries are only reported as deleted when they started after the
+// earliest returned one.
+func (s *Syncer) Resync(ctx context.Context) (int, error) {
+	return s.sync(ctx, 0)
+}
+
+func (s *Syncer) sync(ctx context.Context, since int64) (int, error) {
+	me, next, err := s.client.GetMeRelatedData(ctx, since)
+	if err != nil {
+		return 0, err
+	}
+
+	known := map[SyncKind]map[int]bool{}
+	for kind, ids := range s.state.Known {
+		known[kind] = map[int]bool{}
+		for id := range ids {
+			known[kind][id] = true
+		}
+	}
+	starts := map[int]int64{}
+	for id, start := range s.state.Starts {
+		starts[id] = start
+	}
+	seen := map[SyncKind]map[int]bool{
+		SyncWorkspace: {},
+		SyncProject:   {},
+		SyncTag:       {},
+		SyncTimeEntry: {},
+	}
+
+	events := []ChangeEvent{}
+	add := func(kind SyncKind, id int, at time.Time, deletedAt *time.Time, event ChangeEvent) {
+		seen[kind][id] = true
+		if deletedAt != nil {
+			if known[kind][id] {
+				delete(known[kind], id)
+				if kind == SyncTimeEntry {
+					delete(starts, id)
+				}
+				events = append(events, ChangeEvent{Kind: kind, Action: SyncDelete, ID: id})
+			}
+			return
+		}
+		// Objects not changed since the previous sync are not sent again.
+		if since != 0 && !at.IsZero() && at.Unix() < since && known[kind][id] {
+			return
+		}
+		known[kind][id] = true
+		event.Kind = kind
+		event.Action = SyncUpsert
+		event.ID = id
+		events = append(events, event)
+	}
+
+	for i := range me.Workspaces {
+		w := me.Workspaces[i]
+		add(SyncWorkspace, w.ID, w.At, nil, ChangeEvent{Workspace: &w})
+	}
+	for i := range me.Projects {
+		p := me.Projects[i]
+		add(SyncProject, p.ID, p.At, p.ServerDeletedAt, ChangeEvent{Project: &p})
+	}
+	for i := range me.Tags {
+		t := me.Tags[i]
+		add(SyncTag, t.ID, time.Time{}, t.ServerDeletedAt, ChangeEvent{Tag: &t})
+	}
+	// window is the earliest start of the returned time entries.
+	var window time.Time
+	for i := range me.TimeEntries {
+		te := me.TimeEntries[i]
+		add(SyncTimeEntry, te.ID, te.At, te.ServerDeletedAt, ChangeEvent{TimeEntry: &te})
+		if te.ServerDeletedAt == nil {
+			starts[te.ID] = te.Start.Unix()
+			if window.IsZero() || te.Start.Before(window) {
+				window = te.Start
+			}
+		}
+	}
+
+	if since == 0 {
+		for _, kind := range []SyncKind{SyncWorkspace, SyncProject, SyncTag, SyncTimeEntry} {
+			ids := []int{}
+			for id := range known[kind] {
+				if seen[kind][id] {
+					continue
+				}
+				if kind == SyncTimeEntry {
+					// Time entries started before the window, or of an unknown
+					// start, are not returned by the server but may still exist.
+					start, ok := starts[id]
+					if !ok || window.IsZero() || start < window.Unix() {
+						continue
+					}
+				}
+				ids = append(ids, id)
+			}
+			sort.Ints(ids)
+			for _, id := range ids {
+				delete(known[kind], id)
+				if kind == SyncTimeEntry {
+					delete(starts, id)
+				}
+				events = append(events, ChangeEvent{Kind: kind, Action: SyncDelete, ID: id})
+			}
+		}
+	}
+
+	if len(events) > 0 {
+		if err := s.sink.Apply(ctx, events); err != nil {
+			return 0, err
+		}
+	}
+
+	s.state = SyncState{Since: next, Known: known, Starts: starts}
+	s.client.Debugf("synced %d changes, next since %d", len(events), next)
+
+	return len(events), nil
+}
@@ -0,0 +1,204 @@
+package toggl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type recordingSink struct {
+	events []ChangeEvent
+	err    error
+}
+
+func (s *recordingSink) Apply(ctx context.Context, events []ChangeEvent) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *recordingSink) summary() []string {
+	summary := []string{}
+	for _, e := range s.events {
+		summary = append(summary, fmt.Sprintf("%s %s %d", e.Action, e.Kind, e.ID))
+	}
+	s.events = nil
+	return summary
+}
+
+func TestSyncer(t *testing.T) {
+	at := time.Date(2018, 4, 12, 7, 49, 15, 0, time.UTC)
+	deletedAt := at.Add(time.Hour)
+	responses := map[string]Me{
+		"": {
+			Workspaces:  []Workspace{{ID: 1, At: at}, {ID: 2, At: at}},
+			Projects:    []Project{{ID: 10, Wid: 1, At: at}},
+			Tags:        []Tag{{ID: 20, Wid: 1, Name: "fun"}},
+			TimeEntries: []TimeEntry{{ID: 30, Wid: 1, At: at}, {ID: 31, Wid: 1, At: at}},
+		},
+		"1000": {
+			Projects:    []Project{{ID: 11, Wid: 1, At: at.Add(time.Hour)}},
+			TimeEntries: []TimeEntry{{ID: 30, Wid: 1, At: at.Add(time.Hour)}, {ID: 31, Wid: 1, ServerDeletedAt: &deletedAt}},
+		},
+		"2000": {
+			Workspaces:  []Workspace{{ID: 1, At: at}},
+			Projects:    []Project{{ID: 10, Wid: 1, At: at}, {ID: 11, Wid: 1, At: at.Add(time.Hour)}},
+			Tags:        []Tag{{ID: 20, Wid: 1, Name: "fun"}},
+			TimeEntries: []TimeEntry{{ID: 30, Wid: 1, At: at.Add(time.Hour)}},
+		},
+	}
+	nextSince := map[string]int64{"": 1000, "1000": 2000, "2000": 3000}
+	requested := []string{}
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/api/v8/me" || req.URL.Query().Get("with_related_data") != "true" {
+			return nil, fmt.Errorf("Unexpected request %s", req.URL)
+		}
+		since := req.URL.Query().Get("since")
+		requested = append(requested, since)
+		if len(requested) == 4 {
+			// The last request is a full resync after some deletions.
+			since = "2000"
+		}
+
+		b, err := json.Marshal(struct {
+			Since int64 `json:"since"`
+			Data  Me    `json:"data"`
+		}{
+			Since: nextSince[since],
+			Data:  responses[since],
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+	})
+	api := New("test", OptionHTTPClient(client))
+
+	sink := &recordingSink{}
+	syncer := NewSyncer(api, sink, SyncState{})
+
+	if _, err := syncer.Sync(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	expected := []string{
+		"upsert workspace 1", "upsert workspace 2", "upsert project 10", "upsert tag 20",
+		"upsert time_entry 30", "upsert time_entry 31",
+	}
+	if got := sink.summary(); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected events %v, got %v", expected, got)
+	}
+
+	// A failing sink does not move the cursor.
+	sink.err = errors.New("database is down")
+	if _, err := syncer.Sync(context.Background()); err == nil {
+		t.Fatal(errors.New("Expected the sink error"))
+	}
+	sink.err = nil
+	if syncer.State().Since != 1000 {
+		t.Fatalf("Expected since 1000, got %d", syncer.State().Since)
+	}
+
+	// The cursor survives a restart.
+	syncer = NewSyncer(api, sink, syncer.State())
+	if _, err := syncer.Sync(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	expected = []string{"upsert project 11", "upsert time_entry 30", "delete time_entry 31"}
+	if got := sink.summary(); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected events %v, got %v", expected, got)
+	}
+
+	if _, err := syncer.Resync(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	expected = []string{
+		"upsert workspace 1", "upsert project 10", "upsert project 11", "upsert tag 20",
+		"upsert time_entry 30", "delete workspace 2",
+	}
+	if got := sink.summary(); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected events %v, got %v", expected, got)
+	}
+
+	if !reflect.DeepEqual([]string{"", "1000", "1000", ""}, requested) {
+		t.Fatalf("Unexpected requests %v", requested)
+	}
+}
+
+func TestSyncerResyncKeepsOldTimeEntries(t *testing.T) {
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2018, month, day, 9, 0, 0, 0, time.UTC)
+	}
+	responses := []Me{
+		{TimeEntries: []TimeEntry{
+			{ID: 40, Wid: 1, Start: day(3, 1), At: day(3, 1)},
+			{ID: 41, Wid: 1, Start: day(4, 11), At: day(4, 11)},
+			{ID: 42, Wid: 1, Start: day(4, 10), At: day(4, 10)},
+		}},
+		// Entry 40 is too old to be returned, entry 41 was deleted.
+		{TimeEntries: []TimeEntry{{ID: 42, Wid: 1, Start: day(4, 10), At: day(4, 10)}}},
+	}
+	requests := 0
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/api/v8/me" || req.URL.Query().Get("since") != "" {
+			return nil, fmt.Errorf("Unexpected request %s", req.URL)
+		}
+
+		b, err := json.Marshal(struct {
+			Since int64 `json:"since"`
+			Data  Me    `json:"data"`
+		}{
+			Since: 1000,
+			Data:  responses[requests],
+		})
+		if err != nil {
+			return nil, err
+		}
+		requests++
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+	})
+	api := New("test", OptionHTTPClient(client))
+
+	sink := &recordingSink{}
+	syncer := NewSyncer(api, sink, SyncState{})
+	if _, err := syncer.Resync(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	sink.summary()
+
+	// The starts survive a restart.
+	syncer = NewSyncer(api, sink, syncer.State())
+	if _, err := syncer.Resync(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	expected := []string{"upsert time_entry 42", "delete time_entry 41"}
+	if got := sink.summary(); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected events %v, got %v", expected, got)
+	}
+	if !syncer.State().Known[SyncTimeEntry][40] {
+		t.Fatal(errors.New("Expected time entry 40 to be kept"))
+	}
+}
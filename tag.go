@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // Tag contain all the information of a tag
@@ -12,6 +13,13 @@ type Tag struct {
 	ID   int    `json:"id,omitempty"`
 	Wid  int    `json:"wid,omitempty"`
 	Name string `json:"name"`
+	// ServerDeletedAt is set on tags deleted since the last sync.
+	ServerDeletedAt *time.Time `json:"server_deleted_at,omitempty"`
+}
+
+// TagUpdate is used to partially update a tag. Only the non-nil fields are sent.
+type TagUpdate struct {
+	Name *string `json:"name,omitempty"`
 }
 
 type tagResponse struct {
@@ -70,6 +78,29 @@ func (c *Client) UpdateTag(ctx context.Context, id int, name string) (*Tag, erro
 
 }
 
+// PatchTag updates the fields of the tag set in the update.
+func (c *Client) PatchTag(ctx context.Context, id int, update TagUpdate) (*Tag, error) {
+	spath := fmt.Sprintf("v8/tags/%d", id)
+	response := &tagResponse{}
+
+	params := struct {
+		Tag TagUpdate `json:"tag"`
+	}{
+		Tag: update,
+	}
+	j, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.put(ctx, spath, bytes.NewBuffer(j), response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
 // DeleteTag will delete the tag.
 func (c *Client) DeleteTag(ctx context.Context, id int) error {
 	spath := fmt.Sprintf("v8/tags/%d", id)
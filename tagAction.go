@@ -0,0 +1,72 @@
+package toggl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TagAction defines how the tags of a time entry update are applied.
+type TagAction string
+
+// Tag actions supported by the time entries updates.
+const (
+	TagActionAdd     TagAction = "add"
+	TagActionRemove  TagAction = "remove"
+	TagActionReplace TagAction = "replace"
+)
+
+func (a TagAction) validate() error {
+	switch a {
+	case TagActionAdd, TagActionRemove, TagActionReplace:
+		return nil
+	}
+	return fmt.Errorf("invalid tag action %q", string(a))
+}
+
+// wire returns the tag_action sent to the server, which replaces tags when none is given.
+func (a TagAction) wire() string {
+	if a == TagActionReplace {
+		return ""
+	}
+	return string(a)
+}
+
+// NormalizeTags trims the tag names, collapses their inner whitespace,
+// and drops empty names and case-insensitive duplicates.
+func NormalizeTags(tags []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(tag), " ")
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// AddTagsToEntries adds the normalized tags to every time entry. When some
+// batches fail, the time entries updated by the others are returned with the error.
+func (c *Client) AddTagsToEntries(ctx context.Context, timeEntryIDs []int, tags ...string) (*[]TimeEntry, error) {
+	return c.updateEntriesTags(ctx, timeEntryIDs, tags, TagActionAdd)
+}
+
+// RemoveTagsFromEntries removes the normalized tags from every time entry. When some
+// batches fail, the time entries updated by the others are returned with the error.
+func (c *Client) RemoveTagsFromEntries(ctx context.Context, timeEntryIDs []int, tags ...string) (*[]TimeEntry, error) {
+	return c.updateEntriesTags(ctx, timeEntryIDs, tags, TagActionRemove)
+}
+
+func (c *Client) updateEntriesTags(ctx context.Context, timeEntryIDs []int, tags []string, action TagAction) (*[]TimeEntry, error) {
+	tags = NormalizeTags(tags)
+	if len(tags) == 0 {
+		return nil, errors.New("no tag given")
+	}
+
+	return c.BulkUpdateTimeEntriesTags(ctx, timeEntryIDs, tags, action)
+}
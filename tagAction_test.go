@@ -0,0 +1,125 @@
+package toggl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func newTagActionMockClient(expectedBody string, requested *int) *http.Client {
+	expectedURL := "/api/v8/time_entries/1111111111,2222222222"
+
+	return newMockClient(func(req *http.Request) (*http.Response, error) {
+		*requested++
+		if req.URL.Path != expectedURL {
+			return nil, fmt.Errorf("Expected URL '%s', got %s", expectedURL, req.URL.Path)
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if string(body) != expectedBody {
+			return nil, fmt.Errorf("Expected body '%s', got %s", expectedBody, body)
+		}
+
+		b, err := json.Marshal(struct {
+			Data []TimeEntry `json:"data"`
+		}{
+			Data: []TimeEntry{{ID: 1111111111}, {ID: 2222222222}},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+	})
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags := NormalizeTags([]string{" fun ", "deep   work", "", "Fun", "deep work", "meeting"})
+
+	if !reflect.DeepEqual([]string{"fun", "deep work", "meeting"}, tags) {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+}
+
+func TestAddTagsToEntries(t *testing.T) {
+	requested := 0
+	client := newTagActionMockClient(`{"time_entry":{"tags":["fun","deep work"],"tag_action":"add"}}`, &requested)
+	api := New("test", OptionHTTPClient(client))
+
+	_, err := api.AddTagsToEntries(context.Background(), []int{1111111111, 2222222222}, "fun", " deep  work", "FUN")
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if requested != 1 {
+		t.Fatalf("Expected 1 request, got %d", requested)
+	}
+}
+
+func TestAddTagsToEntriesPartialFailure(t *testing.T) {
+	defer func(size int) { BulkBatchSize = size }(BulkBatchSize)
+	BulkBatchSize = 1
+
+	requested := []string{}
+	api := New("test", OptionHTTPClient(newBulkMockClient("2", &requested)))
+
+	timeEntries, err := api.AddTagsToEntries(context.Background(), []int{1, 2}, "fun")
+	if err == nil {
+		t.Fatal(errors.New("Expected an error for the failed batch"))
+	}
+	if timeEntries == nil || !reflect.DeepEqual([]TimeEntry{{ID: 1}}, *timeEntries) {
+		t.Fatalf("Expected the updated time entries along with the error, got %v", timeEntries)
+	}
+}
+
+func TestRemoveTagsFromEntries(t *testing.T) {
+	requested := 0
+	client := newTagActionMockClient(`{"time_entry":{"tags":["fun"],"tag_action":"remove"}}`, &requested)
+	api := New("test", OptionHTTPClient(client))
+
+	_, err := api.RemoveTagsFromEntries(context.Background(), []int{1111111111, 2222222222}, "fun")
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	_, err = api.RemoveTagsFromEntries(context.Background(), []int{1111111111, 2222222222}, " ")
+	if err == nil {
+		t.Fatal(errors.New("Expected an error without tags"))
+	}
+	if requested != 1 {
+		t.Fatalf("Expected 1 request, got %d", requested)
+	}
+}
+
+func TestBulkUpdateTimeEntriesTagsAction(t *testing.T) {
+	requested := 0
+	client := newTagActionMockClient(`{"time_entry":{"tags":["fun"]}}`, &requested)
+	api := New("test", OptionHTTPClient(client))
+
+	_, err := api.BulkUpdateTimeEntriesTags(context.Background(), []int{1111111111, 2222222222}, []string{"fun"}, TagActionReplace)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	_, err = api.BulkUpdateTimeEntriesTags(context.Background(), []int{1111111111, 2222222222}, []string{"fun"}, "append")
+	if err == nil {
+		t.Fatal(errors.New("Expected an error for an invalid tag action"))
+	}
+	if requested != 1 {
+		t.Fatalf("Expected 1 request, got %d", requested)
+	}
+}
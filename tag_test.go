@@ -111,3 +111,48 @@ func TestDeleteTag(t *testing.T) {
 		return
 	}
 }
+
+func TestPatchTag(t *testing.T) {
+	expected := getTestTag()
+	expectedURL := "/api/v8/tags/5740596"
+	expectedBody := `{"tag":{"name":"fun"}}`
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if !strings.HasPrefix(req.URL.Path, expectedURL) {
+			return nil, fmt.Errorf("Expected URL '%s', got %s", expectedURL, req.URL.Path)
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if string(body) != expectedBody {
+			return nil, fmt.Errorf("Expected body '%s', got %s", expectedBody, body)
+		}
+
+		b, err := json.Marshal(struct {
+			Data Tag `json:"data"`
+		}{
+			Data: getTestTag(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+
+	})
+	api := New("test", OptionHTTPClient(client))
+
+	tag, err := api.PatchTag(context.Background(), 5740596, TagUpdate{Name: String("fun")})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if !reflect.DeepEqual(expected, *tag) {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+}
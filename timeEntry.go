@@ -9,6 +9,9 @@ import (
 	"time"
 )
 
+// defaultCreatedWith is sent as created_with by the calls not taking one.
+const defaultCreatedWith = "otms61/toggl"
+
 // TimeEntry contain all the information of a time entries
 type TimeEntry struct {
 	ID          int       `json:"id"`
@@ -24,18 +27,41 @@ type TimeEntry struct {
 	At          time.Time `json:"at"`
 	UID         int       `json:"uid"`
 	Tags        []string  `json:"tags"`
+	// ServerDeletedAt is set on time entries deleted since the last sync.
+	ServerDeletedAt *time.Time `json:"server_deleted_at,omitempty"`
 }
 
 // TimeEntryRequest is used to create time entry
 type TimeEntryRequest struct {
+	GUID        string   `json:"guid,omitempty"`
 	Description string   `json:"description"`
 	Tags        []string `json:"tags"`
 	Start       string   `json:"start,omitempty"`
+	Stop        string   `json:"stop,omitempty"`
 	Duration    int      `json:"duration,omitempty"`
+	Duronly     bool     `json:"duronly,omitempty"`
 	Pid         int      `json:"pid"`
+	Wid         int      `json:"wid,omitempty"`
+	Tid         int      `json:"tid,omitempty"`
+	Billable    bool     `json:"billable,omitempty"`
 	CreatedWith string   `json:"created_with"`
 }
 
+// TimeEntryUpdate is used to partially update a time entry. Only the non-nil fields are sent.
+type TimeEntryUpdate struct {
+	Description *string    `json:"description,omitempty"`
+	Tags        *[]string  `json:"tags,omitempty"`
+	TagAction   TagAction  `json:"tag_action,omitempty"`
+	Start       *time.Time `json:"start,omitempty"`
+	Stop        *time.Time `json:"stop,omitempty"`
+	Duration    *int       `json:"duration,omitempty"`
+	Duronly     *bool      `json:"duronly,omitempty"`
+	Pid         *int       `json:"pid,omitempty"`
+	Wid         *int       `json:"wid,omitempty"`
+	Tid         *int       `json:"tid,omitempty"`
+	Billable    *bool      `json:"billable,omitempty"`
+}
+
 // TimeEntryResponse is the wrapper of the TimeEntry for API server specification.
 type TimeEntryResponse struct {
 	Data TimeEntry `json:"data"`
@@ -44,7 +70,7 @@ type TimeEntryResponse struct {
 // BulkUpdateTagsRequest is the wrapper of the TimeEntry for API server specification.
 type BulkUpdateTagsRequest struct {
 	Tags      []string `json:"tags"`
-	TagAction string   `json:"tag_action"`
+	TagAction string   `json:"tag_action,omitempty"`
 }
 
 // BulkUpdateTagsResponse is the wrapper of the TimeEntry for API server specification.
@@ -52,17 +78,61 @@ type BulkUpdateTagsResponse struct {
 	Data []TimeEntry `json:"data"`
 }
 
+type runningTimeEntryResponse struct {
+	Data *TimeEntry `json:"data"`
+}
+
+// IsRunning reports whether the time entry is still running.
+// Toggl encodes the duration of a running entry as its negative start epoch.
+func (t TimeEntry) IsRunning() bool {
+	return t.Duration < 0
+}
+
+// DurationValue returns the recorded duration of a stopped time entry.
+// It is zero for a running entry, use Elapsed instead.
+func (t TimeEntry) DurationValue() time.Duration {
+	if t.IsRunning() {
+		return 0
+	}
+	return time.Duration(t.Duration) * time.Second
+}
+
+// Elapsed returns the duration of the time entry, measuring a running entry up to now.
+func (t TimeEntry) Elapsed(now time.Time) time.Duration {
+	if !t.IsRunning() {
+		return t.DurationValue()
+	}
+
+	start := t.Start
+	if start.IsZero() {
+		start = time.Unix(int64(-t.Duration), 0)
+	}
+	return now.Sub(start)
+}
+
+// EffectiveStop returns when the time entry stopped, or now for a running entry.
+func (t TimeEntry) EffectiveStop(now time.Time) time.Time {
+	if t.IsRunning() {
+		return now
+	}
+	if t.Stop.IsZero() {
+		return t.Start.Add(t.DurationValue())
+	}
+	return t.Stop
+}
+
 // GetRunningTimeEntry will retrive running time entry.
+// It returns nil without error when no time entry is running.
 func (c *Client) GetRunningTimeEntry(ctx context.Context) (*TimeEntry, error) {
 	spath := "v8/time_entries/current"
-	response := &TimeEntryResponse{}
+	response := &runningTimeEntryResponse{}
 
 	err := c.get(ctx, spath, nil, response)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response.Data, nil
+	return response.Data, nil
 }
 
 // GetTimeEntries will retrive time entries in specific time range.
@@ -71,8 +141,8 @@ func (c *Client) GetTimeEntries(ctx context.Context, start, end time.Time) (*[]T
 	response := &[]TimeEntry{}
 
 	params := url.Values{}
-	params.Add("start_date", start.Format("2006-01-02T15:04:05+09:00"))
-	params.Add("end_date", end.Format("2006-01-02T15:04:05+09:00"))
+	params.Add("start_date", start.Format(time.RFC3339))
+	params.Add("end_date", end.Format(time.RFC3339))
 
 	err := c.get(ctx, spath, params, response)
 	if err != nil {
@@ -125,15 +195,104 @@ func (c *Client) StartTimeEntry(ctx context.Context, projectID int, description
 
 // StopTimeEntry will stop the running time entry.
 func (c *Client) StopTimeEntry(ctx context.Context, id int) error {
+	_, err := c.stopTimeEntry(ctx, id)
+	return err
+}
+
+func (c *Client) stopTimeEntry(ctx context.Context, id int) (*TimeEntry, error) {
 	spath := fmt.Sprintf("v8/time_entries/%d/stop", id)
 	response := &TimeEntryResponse{}
 
 	err := c.put(ctx, spath, nil, response)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &response.Data, nil
+}
+
+// StopTimeEntryAt will stop the time entry at the given time and return the stopped entry.
+func (c *Client) StopTimeEntryAt(ctx context.Context, id int, stop time.Time) (*TimeEntry, error) {
+	timeEntry, err := c.GetTimeEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !stop.After(timeEntry.Start) {
+		return nil, fmt.Errorf("stop time %s is not after the start of time entry %d", stop.Format(time.RFC3339), id)
+	}
+
+	spath := fmt.Sprintf("v8/time_entries/%d", id)
+	response := &TimeEntryResponse{}
+
+	params := struct {
+		TimeEntry struct {
+			Stop     string `json:"stop"`
+			Duration int    `json:"duration"`
+		} `json:"time_entry"`
+	}{}
+	params.TimeEntry.Stop = stop.Format(time.RFC3339)
+	params.TimeEntry.Duration = int(stop.Sub(timeEntry.Start) / time.Second)
+	j, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.put(ctx, spath, bytes.NewBuffer(j), response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// StopCurrent will stop the running time entry, whichever it is, and return it.
+// It returns nil without error when no time entry is running.
+func (c *Client) StopCurrent(ctx context.Context) (*TimeEntry, error) {
+	running, err := c.GetRunningTimeEntry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if running == nil {
+		return nil, nil
+	}
+
+	return c.stopTimeEntry(ctx, running.ID)
+}
+
+// ContinueTimeEntry starts a new time entry with the description, project,
+// tags and billable flag of an existing time entry.
+func (c *Client) ContinueTimeEntry(ctx context.Context, id int) (*TimeEntry, error) {
+	timeEntry, err := c.GetTimeEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	spath := "v8/time_entries/start"
+	response := &TimeEntryResponse{}
+
+	params := struct {
+		TimeEntry TimeEntryRequest `json:"time_entry"`
+	}{
+		TimeEntry: TimeEntryRequest{
+			Description: timeEntry.Description,
+			Tags:        timeEntry.Tags,
+			Pid:         timeEntry.Pid,
+			Wid:         timeEntry.Wid,
+			Billable:    timeEntry.Billable,
+			CreatedWith: defaultCreatedWith,
+		},
+	}
+	j, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.post(ctx, spath, bytes.NewBuffer(j), response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
 }
 
 // CreateTimeEntry creates a new time entry based in the given configuration.
@@ -147,7 +306,7 @@ func (c *Client) CreateTimeEntry(ctx context.Context, projectID int, description
 		TimeEntry: TimeEntryRequest{
 			Description: description,
 			Tags:        tags,
-			Start:       start.Format("2006-01-02T15:04:05+09:00"),
+			Start:       start.Format(time.RFC3339),
 			Duration:    duration,
 			Pid:         projectID,
 			CreatedWith: createdWith,
@@ -166,7 +325,8 @@ func (c *Client) CreateTimeEntry(ctx context.Context, projectID int, description
 	return &response.Data, nil
 }
 
-// UpdateTimeEntry creates a new project based in the given configuration.
+// UpdateTimeEntry updates every field of the time entry based in the given configuration.
+// Use PatchTimeEntry to update some fields only.
 func (c *Client) UpdateTimeEntry(ctx context.Context, timeEntryID int, projectID int, description string, start time.Time, duration int, tags []string, createdWith string) (*TimeEntry, error) {
 	spath := fmt.Sprintf("v8/time_entries/%d", timeEntryID)
 	response := &TimeEntryResponse{}
@@ -177,7 +337,7 @@ func (c *Client) UpdateTimeEntry(ctx context.Context, timeEntryID int, projectID
 		TimeEntry: TimeEntryRequest{
 			Description: description,
 			Tags:        tags,
-			Start:       start.Format("2006-01-02T15:04:05+09:00"),
+			Start:       start.Format(time.RFC3339),
 			Duration:    duration,
 			Pid:         projectID,
 			CreatedWith: createdWith,
@@ -196,6 +356,33 @@ func (c *Client) UpdateTimeEntry(ctx context.Context, timeEntryID int, projectID
 	return &response.Data, nil
 }
 
+// PatchTimeEntry updates the fields of the time entry set in the update.
+func (c *Client) PatchTimeEntry(ctx context.Context, id int, update TimeEntryUpdate) (*TimeEntry, error) {
+	if err := update.validate(); err != nil {
+		return nil, err
+	}
+
+	spath := fmt.Sprintf("v8/time_entries/%d", id)
+	response := &TimeEntryResponse{}
+
+	params := struct {
+		TimeEntry TimeEntryUpdate `json:"time_entry"`
+	}{
+		TimeEntry: update.request(),
+	}
+	j, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.put(ctx, spath, bytes.NewBuffer(j), response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
 // DeleteTimeEntry will delete the time entry.
 func (c *Client) DeleteTimeEntry(ctx context.Context, id int) error {
 	spath := fmt.Sprintf("v8/time_entries/%d", id)
@@ -209,38 +396,27 @@ func (c *Client) DeleteTimeEntry(ctx context.Context, id int) error {
 	return nil
 }
 
-// BulkUpdateTimeEntriesTags creates a new project based in the given configuration.
-func (c *Client) BulkUpdateTimeEntriesTags(ctx context.Context, timeEntryIDs []int, tags []string, action string) (*[]TimeEntry, error) {
-	// BulkUpdate endpoint needs ID separated by ",".
-	var ids bytes.Buffer
-	ids.WriteString(fmt.Sprint(timeEntryIDs[0]))
-	timeEntryIDs = timeEntryIDs[1:]
-	for _, ID := range timeEntryIDs {
-		ids.WriteString(",")
-		s := fmt.Sprint(ID)
-		ids.WriteString(s)
+// BulkUpdateTimeEntriesTags updates the tags of the time entries. Large ID sets are sent in batches.
+// When any of the time entries could not be updated, the error is returned along with the time
+// entries the other batches updated. Use BulkUpdateTimeEntries for the per entry failures.
+func (c *Client) BulkUpdateTimeEntriesTags(ctx context.Context, timeEntryIDs []int, tags []string, action TagAction) (*[]TimeEntry, error) {
+	if err := action.validate(); err != nil {
+		return nil, err
 	}
 
-	spath := fmt.Sprintf("v8/time_entries/%s", ids.String())
-	response := &BulkUpdateTagsResponse{}
-
 	params := struct {
 		TimeEntry BulkUpdateTagsRequest `json:"time_entry"`
 	}{
 		TimeEntry: BulkUpdateTagsRequest{
 			Tags:      tags,
-			TagAction: action,
+			TagAction: action.wire(),
 		},
 	}
-	j, err := json.Marshal(params)
-	if err != nil {
-		return nil, err
-	}
 
-	err = c.put(ctx, spath, bytes.NewBuffer(j), response)
+	result, err := c.bulkUpdateTimeEntries(ctx, timeEntryIDs, params)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response.Data, nil
+	return &result.TimeEntries, result.Err()
 }
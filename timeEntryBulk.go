@@ -0,0 +1,158 @@
+package toggl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BulkBatchSize is the number of time entry IDs sent per bulk request,
+// keeping the request URL under the server limits.
+var BulkBatchSize = 100
+
+// BulkResult reports which time entries a bulk operation succeeded or failed for.
+type BulkResult struct {
+	Succeeded []int
+	Failed    map[int]error
+	// TimeEntries holds the updated time entries returned by the server.
+	TimeEntries []TimeEntry
+}
+
+// Err returns an error describing the failed time entries, or nil if all succeeded.
+func (r *BulkResult) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(r.Failed))
+	for id := range r.Failed {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	return fmt.Errorf("bulk operation failed for %d time entries, first %d: %s", len(ids), ids[0], r.Failed[ids[0]])
+}
+
+// BulkUpdateTimeEntries applies the update to every time entry.
+// Failures of single batches are reported in the result rather than as an error.
+func (c *Client) BulkUpdateTimeEntries(ctx context.Context, timeEntryIDs []int, update TimeEntryUpdate) (*BulkResult, error) {
+	if err := update.validate(); err != nil {
+		return nil, err
+	}
+
+	params := struct {
+		TimeEntry TimeEntryUpdate `json:"time_entry"`
+	}{
+		TimeEntry: update.request(),
+	}
+
+	return c.bulkUpdateTimeEntries(ctx, timeEntryIDs, params)
+}
+
+// BulkDeleteTimeEntries deletes every time entry.
+// Failures of single batches are reported in the result rather than as an error.
+func (c *Client) BulkDeleteTimeEntries(ctx context.Context, timeEntryIDs []int) (*BulkResult, error) {
+	return c.bulkTimeEntries(timeEntryIDs, func(spath string) ([]int, []TimeEntry, error) {
+		response := &[]int{}
+
+		err := c.delete(ctx, spath, nil, response)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return *response, nil, nil
+	})
+}
+
+func (c *Client) bulkUpdateTimeEntries(ctx context.Context, timeEntryIDs []int, params interface{}) (*BulkResult, error) {
+	j, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.bulkTimeEntries(timeEntryIDs, func(spath string) ([]int, []TimeEntry, error) {
+		response := &BulkUpdateTagsResponse{}
+
+		err := c.put(ctx, spath, bytes.NewReader(j), response)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ids := make([]int, 0, len(response.Data))
+		for _, te := range response.Data {
+			ids = append(ids, te.ID)
+		}
+		return ids, response.Data, nil
+	})
+}
+
+// bulkTimeEntries splits the IDs into batches and calls do with the path of each batch.
+// do returns the IDs the server processed, and the time entries it returned if any.
+func (c *Client) bulkTimeEntries(timeEntryIDs []int, do func(spath string) ([]int, []TimeEntry, error)) (*BulkResult, error) {
+	if len(timeEntryIDs) == 0 {
+		return nil, errors.New("bulk operation needs at least one time entry ID")
+	}
+
+	ids := []int{}
+	seen := map[int]bool{}
+	for _, id := range timeEntryIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	batchSize := BulkBatchSize
+	if batchSize <= 0 {
+		batchSize = len(ids)
+	}
+
+	result := &BulkResult{
+		Succeeded:   []int{},
+		Failed:      map[int]error{},
+		TimeEntries: []TimeEntry{},
+	}
+	for len(ids) > 0 {
+		n := batchSize
+		if n > len(ids) {
+			n = len(ids)
+		}
+		batch := ids[:n]
+		ids = ids[n:]
+
+		// Bulk endpoints need ID separated by ",".
+		s := make([]string, len(batch))
+		for i, id := range batch {
+			s[i] = strconv.Itoa(id)
+		}
+		spath := fmt.Sprintf("v8/time_entries/%s", strings.Join(s, ","))
+
+		processed, timeEntries, err := do(spath)
+		if err != nil {
+			for _, id := range batch {
+				result.Failed[id] = err
+			}
+			continue
+		}
+
+		done := map[int]bool{}
+		for _, id := range processed {
+			done[id] = true
+		}
+		for _, id := range batch {
+			if done[id] {
+				result.Succeeded = append(result.Succeeded, id)
+			} else {
+				result.Failed[id] = fmt.Errorf("time entry %d was not processed by the server", id)
+			}
+		}
+		result.TimeEntries = append(result.TimeEntries, timeEntries...)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,164 @@
+package toggl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func newBulkMockClient(failing string, requested *[]string) *http.Client {
+	return newMockClient(func(req *http.Request) (*http.Response, error) {
+		if !strings.HasPrefix(req.URL.Path, "/api/v8/time_entries/") {
+			return nil, fmt.Errorf("Expected URL '%s', got %s", "/api/v8/time_entries/", req.URL.Path)
+		}
+
+		ids := strings.TrimPrefix(req.URL.Path, "/api/v8/time_entries/")
+		*requested = append(*requested, req.Method+" "+ids)
+		if ids == failing {
+			return &http.Response{
+				StatusCode: http.StatusInternalServerError,
+				Status:     "500 Internal Server Error",
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			}, nil
+		}
+
+		var v interface{}
+		deleted := []int{}
+		updated := []TimeEntry{}
+		for _, s := range strings.Split(ids, ",") {
+			id, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, err
+			}
+			deleted = append(deleted, id)
+			updated = append(updated, TimeEntry{ID: id})
+		}
+		if req.Method == "DELETE" {
+			v = deleted
+		} else {
+			v = struct {
+				Data []TimeEntry `json:"data"`
+			}{
+				Data: updated,
+			}
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+	})
+}
+
+func TestBulkUpdateTimeEntries(t *testing.T) {
+	defer func(size int) { BulkBatchSize = size }(BulkBatchSize)
+	BulkBatchSize = 2
+
+	requested := []string{}
+	api := New("test", OptionHTTPClient(newBulkMockClient("3,4", &requested)))
+
+	result, err := api.BulkUpdateTimeEntries(context.Background(), []int{1, 2, 3, 4, 5, 1}, TimeEntryUpdate{
+		Pid:      Int(123456789),
+		Billable: Bool(true),
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	expectedRequests := []string{"PUT 1,2", "PUT 3,4", "PUT 5"}
+	if !reflect.DeepEqual(expectedRequests, requested) {
+		t.Fatalf("Expected requests %v, got %v", expectedRequests, requested)
+	}
+	if !reflect.DeepEqual([]int{1, 2, 5}, result.Succeeded) {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+	if len(result.Failed) != 2 || result.Failed[3] == nil || result.Failed[4] == nil {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+	if len(result.TimeEntries) != 3 {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+	if result.Err() == nil {
+		t.Fatal(errors.New("Expected an aggregated error"))
+	}
+}
+
+func TestBulkDeleteTimeEntries(t *testing.T) {
+	requested := []string{}
+	api := New("test", OptionHTTPClient(newBulkMockClient("", &requested)))
+
+	result, err := api.BulkDeleteTimeEntries(context.Background(), []int{1, 2, 3})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	if !reflect.DeepEqual([]string{"DELETE 1,2,3"}, requested) {
+		t.Fatalf("Unexpected requests %v", requested)
+	}
+	if !reflect.DeepEqual([]int{1, 2, 3}, result.Succeeded) || result.Err() != nil {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+}
+
+func TestBulkUpdateTimeEntriesTags(t *testing.T) {
+	requested := []string{}
+	api := New("test", OptionHTTPClient(newBulkMockClient("", &requested)))
+
+	timeEntries, err := api.BulkUpdateTimeEntriesTags(context.Background(), []int{1, 2}, []string{"fun"}, "add")
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if !reflect.DeepEqual([]TimeEntry{{ID: 1}, {ID: 2}}, *timeEntries) {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+}
+
+func TestBulkUpdateTimeEntriesTagsPartialFailure(t *testing.T) {
+	defer func(size int) { BulkBatchSize = size }(BulkBatchSize)
+	BulkBatchSize = 2
+
+	requested := []string{}
+	api := New("test", OptionHTTPClient(newBulkMockClient("3,4", &requested)))
+
+	timeEntries, err := api.BulkUpdateTimeEntriesTags(context.Background(), []int{1, 2, 3, 4, 5}, []string{"fun"}, TagActionAdd)
+	if err == nil {
+		t.Fatal(errors.New("Expected an error for the failed batch"))
+	}
+	if timeEntries == nil || !reflect.DeepEqual([]TimeEntry{{ID: 1}, {ID: 2}, {ID: 5}}, *timeEntries) {
+		t.Fatalf("Expected the updated time entries along with the error, got %v", timeEntries)
+	}
+}
+
+func TestBulkEmptyInput(t *testing.T) {
+	requested := []string{}
+	api := New("test", OptionHTTPClient(newBulkMockClient("", &requested)))
+
+	if _, err := api.BulkUpdateTimeEntriesTags(context.Background(), nil, []string{"fun"}, "add"); err == nil {
+		t.Fatal(errors.New("Expected an error for empty input"))
+	}
+	if _, err := api.BulkUpdateTimeEntries(context.Background(), []int{}, TimeEntryUpdate{}); err == nil {
+		t.Fatal(errors.New("Expected an error for empty input"))
+	}
+	if _, err := api.BulkDeleteTimeEntries(context.Background(), nil); err == nil {
+		t.Fatal(errors.New("Expected an error for empty input"))
+	}
+	if len(requested) != 0 {
+		t.Fatalf("Unexpected requests %v", requested)
+	}
+}
@@ -0,0 +1,176 @@
+package toggl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeEntryOptions holds the configuration of a time entry created by
+// CreateTimeEntryWithOptions or StartTimeEntryWithOptions.
+type TimeEntryOptions struct {
+	Description string
+	Tags        []string
+	// Wid, Pid or Tid must be set. Without a project the entry goes to the workspace.
+	Wid      int
+	Pid      int
+	Tid      int
+	Billable bool
+	// Start is required to create an entry. A running entry starts now unless set.
+	Start time.Time
+	// Stop and Duration are alternatives to each other, Duration is computed from Stop when unset.
+	Stop        time.Time
+	Duration    time.Duration
+	Duronly     bool
+	CreatedWith string
+	// GUID is a client generated identifier sent with the time entry, so that
+	// it can be found when the response to its creation is lost.
+	GUID string
+}
+
+// Validate checks the options of a stopped time entry, as CreateTimeEntryWithOptions does.
+func (o TimeEntryOptions) Validate() error {
+	return o.validate(false)
+}
+
+func (o TimeEntryOptions) validate(running bool) error {
+	if o.Wid == 0 && o.Pid == 0 && o.Tid == 0 {
+		return errors.New("time entry needs a workspace, project or task")
+	}
+	if o.Duration < 0 {
+		return fmt.Errorf("negative time entry duration %s", o.Duration)
+	}
+
+	if running {
+		if !o.Stop.IsZero() || o.Duration != 0 {
+			return errors.New("running time entry cannot have a stop or duration")
+		}
+		return nil
+	}
+
+	if o.Start.IsZero() {
+		return errors.New("time entry needs a start")
+	}
+	if !o.Stop.IsZero() {
+		if !o.Stop.After(o.Start) {
+			return fmt.Errorf("time entry stop %s is not after start %s", o.Stop.Format(time.RFC3339), o.Start.Format(time.RFC3339))
+		}
+		if o.Duration != 0 && o.Duration != o.Stop.Sub(o.Start) {
+			return fmt.Errorf("time entry duration %s does not match its start and stop", o.Duration)
+		}
+	} else if o.Duration == 0 {
+		return errors.New("time entry needs a stop or duration")
+	}
+
+	return nil
+}
+
+func (o TimeEntryOptions) request(running bool) TimeEntryRequest {
+	r := TimeEntryRequest{
+		GUID:        o.GUID,
+		Description: o.Description,
+		Tags:        o.Tags,
+		Duronly:     o.Duronly,
+		Pid:         o.Pid,
+		Wid:         o.Wid,
+		Tid:         o.Tid,
+		Billable:    o.Billable,
+		CreatedWith: o.CreatedWith,
+	}
+	if r.CreatedWith == "" {
+		r.CreatedWith = defaultCreatedWith
+	}
+
+	if !o.Start.IsZero() {
+		r.Start = o.Start.Format(time.RFC3339)
+	}
+	if running {
+		if !o.Start.IsZero() {
+			r.Duration = -int(o.Start.Unix())
+		}
+		return r
+	}
+
+	duration := o.Duration
+	if !o.Stop.IsZero() {
+		r.Stop = o.Stop.Format(time.RFC3339)
+		duration = o.Stop.Sub(o.Start)
+	}
+	r.Duration = int(duration / time.Second)
+
+	return r
+}
+
+func (u TimeEntryUpdate) validate() error {
+	if u.Start != nil && u.Stop != nil && !u.Stop.After(*u.Start) {
+		return fmt.Errorf("time entry stop %s is not after start %s", u.Stop.Format(time.RFC3339), u.Start.Format(time.RFC3339))
+	}
+	if u.Start != nil && u.Stop != nil && u.Duration != nil && *u.Duration != int(u.Stop.Sub(*u.Start)/time.Second) {
+		return fmt.Errorf("time entry duration %d does not match its start and stop", *u.Duration)
+	}
+	if u.TagAction != "" {
+		if err := u.TagAction.validate(); err != nil {
+			return err
+		}
+		if u.Tags == nil {
+			return errors.New("tag action needs tags")
+		}
+	}
+
+	return nil
+}
+
+// request returns the update as sent to the server.
+func (u TimeEntryUpdate) request() TimeEntryUpdate {
+	u.TagAction = TagAction(u.TagAction.wire())
+	return u
+}
+
+// CreateTimeEntryWithOptions creates a new stopped time entry based in the given options.
+func (c *Client) CreateTimeEntryWithOptions(ctx context.Context, opts TimeEntryOptions) (*TimeEntry, error) {
+	if err := opts.validate(false); err != nil {
+		return nil, err
+	}
+
+	return c.postTimeEntry(ctx, "v8/time_entries", opts.request(false))
+}
+
+// StartTimeEntryWithOptions creates a new running time entry based in the given options.
+func (c *Client) StartTimeEntryWithOptions(ctx context.Context, opts TimeEntryOptions) (*TimeEntry, error) {
+	if err := opts.validate(true); err != nil {
+		return nil, err
+	}
+
+	spath := "v8/time_entries/start"
+	if !opts.Start.IsZero() {
+		// The start endpoint always starts now, a running entry
+		// starting in the past is created with a negative duration.
+		spath = "v8/time_entries"
+	}
+
+	return c.postTimeEntry(ctx, spath, opts.request(true))
+}
+
+func (c *Client) postTimeEntry(ctx context.Context, spath string, request TimeEntryRequest) (*TimeEntry, error) {
+	response := &TimeEntryResponse{}
+
+	params := struct {
+		TimeEntry TimeEntryRequest `json:"time_entry"`
+	}{
+		TimeEntry: request,
+	}
+	j, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.post(ctx, spath, bytes.NewBuffer(j), response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
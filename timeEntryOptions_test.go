@@ -0,0 +1,181 @@
+package toggl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTimeEntryBodyMockClient(expectedURL, expectedBody string) *http.Client {
+	return newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != expectedURL {
+			return nil, fmt.Errorf("Expected URL '%s', got %s", expectedURL, req.URL.Path)
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if string(body) != expectedBody {
+			return nil, fmt.Errorf("Expected body '%s', got %s", expectedBody, body)
+		}
+
+		b, err := json.Marshal(struct {
+			Data TimeEntry `json:"data"`
+		}{
+			Data: getTestTimeEntry(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+	})
+}
+
+func TestCreateTimeEntryWithOptions(t *testing.T) {
+	expected := getTestTimeEntry()
+	expectedURL := "/api/v8/time_entries"
+	expectedBody := `{"time_entry":{"description":"toggl test","tags":["fun"],"start":"2018-04-12T07:49:00Z","stop":"2018-04-12T08:19:00Z","duration":1800,"pid":0,"wid":3278506,"tid":42,"billable":true,"created_with":"golang"}}`
+
+	api := New("test", OptionHTTPClient(newTimeEntryBodyMockClient(expectedURL, expectedBody)))
+
+	start := time.Date(2018, 4, 12, 7, 49, 0, 0, time.UTC)
+	timeEntry, err := api.CreateTimeEntryWithOptions(context.Background(), TimeEntryOptions{
+		Description: "toggl test",
+		Tags:        []string{"fun"},
+		Wid:         3278506,
+		Tid:         42,
+		Billable:    true,
+		Start:       start,
+		Stop:        start.Add(30 * time.Minute),
+		CreatedWith: "golang",
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if !reflect.DeepEqual(expected, *timeEntry) {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+}
+
+func TestCreateTimeEntryWithOptionsDuronly(t *testing.T) {
+	expectedURL := "/api/v8/time_entries"
+	expectedBody := `{"time_entry":{"description":"","tags":null,"start":"2018-04-12T07:49:00Z","duration":600,"duronly":true,"pid":123456789,"created_with":"otms61/toggl"}}`
+
+	api := New("test", OptionHTTPClient(newTimeEntryBodyMockClient(expectedURL, expectedBody)))
+
+	_, err := api.CreateTimeEntryWithOptions(context.Background(), TimeEntryOptions{
+		Pid:      123456789,
+		Start:    time.Date(2018, 4, 12, 7, 49, 0, 0, time.UTC),
+		Duration: 10 * time.Minute,
+		Duronly:  true,
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+}
+
+func TestStartTimeEntryWithOptions(t *testing.T) {
+	start := time.Date(2018, 4, 12, 7, 49, 0, 0, time.UTC)
+
+	tests := []struct {
+		opts         TimeEntryOptions
+		expectedURL  string
+		expectedBody string
+	}{
+		{
+			opts:         TimeEntryOptions{Description: "toggl test", Wid: 3278506, Billable: true},
+			expectedURL:  "/api/v8/time_entries/start",
+			expectedBody: `{"time_entry":{"description":"toggl test","tags":null,"pid":0,"wid":3278506,"billable":true,"created_with":"otms61/toggl"}}`,
+		},
+		{
+			opts:         TimeEntryOptions{Description: "toggl test", Pid: 123456789, Start: start},
+			expectedURL:  "/api/v8/time_entries",
+			expectedBody: `{"time_entry":{"description":"toggl test","tags":null,"start":"2018-04-12T07:49:00Z","duration":-1523519340,"pid":123456789,"created_with":"otms61/toggl"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		api := New("test", OptionHTTPClient(newTimeEntryBodyMockClient(test.expectedURL, test.expectedBody)))
+
+		_, err := api.StartTimeEntryWithOptions(context.Background(), test.opts)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+	}
+}
+
+func TestTimeEntryOptionsValidate(t *testing.T) {
+	start := time.Date(2018, 4, 12, 7, 49, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		opts    TimeEntryOptions
+		running bool
+		valid   bool
+	}{
+		{"duration", TimeEntryOptions{Pid: 1, Start: start, Duration: time.Minute}, false, true},
+		{"stop", TimeEntryOptions{Wid: 1, Start: start, Stop: start.Add(time.Minute)}, false, true},
+		{"matching stop and duration", TimeEntryOptions{Wid: 1, Start: start, Stop: start.Add(time.Minute), Duration: time.Minute}, false, true},
+		{"no workspace", TimeEntryOptions{Start: start, Duration: time.Minute}, false, false},
+		{"no start", TimeEntryOptions{Pid: 1, Duration: time.Minute}, false, false},
+		{"no stop nor duration", TimeEntryOptions{Pid: 1, Start: start}, false, false},
+		{"stop before start", TimeEntryOptions{Pid: 1, Start: start, Stop: start.Add(-time.Minute)}, false, false},
+		{"mismatching stop and duration", TimeEntryOptions{Pid: 1, Start: start, Stop: start.Add(time.Minute), Duration: time.Hour}, false, false},
+		{"negative duration", TimeEntryOptions{Pid: 1, Start: start, Duration: -time.Minute}, false, false},
+		{"running", TimeEntryOptions{Pid: 1}, true, true},
+		{"running with stop", TimeEntryOptions{Pid: 1, Start: start, Stop: start.Add(time.Minute)}, true, false},
+	}
+
+	for _, test := range tests {
+		err := test.opts.validate(test.running)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
+
+func TestTimeEntryUpdateValidate(t *testing.T) {
+	start := time.Date(2018, 4, 12, 7, 49, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		update TimeEntryUpdate
+		valid  bool
+	}{
+		{"empty", TimeEntryUpdate{}, true},
+		{"start and stop", TimeEntryUpdate{Start: Time(start), Stop: Time(start.Add(time.Minute)), Duration: Int(60)}, true},
+		{"stop before start", TimeEntryUpdate{Start: Time(start), Stop: Time(start.Add(-time.Minute))}, false},
+		{"mismatching duration", TimeEntryUpdate{Start: Time(start), Stop: Time(start.Add(time.Minute)), Duration: Int(30)}, false},
+		{"tag action", TimeEntryUpdate{Tags: Strings("fun"), TagAction: TagActionRemove}, true},
+		{"tag action without tags", TimeEntryUpdate{TagAction: TagActionAdd}, false},
+		{"invalid tag action", TimeEntryUpdate{Tags: Strings("fun"), TagAction: "append"}, false},
+	}
+
+	for _, test := range tests {
+		err := test.update.validate()
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
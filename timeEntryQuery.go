@@ -0,0 +1,228 @@
+package toggl
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TimeEntrySortKey selects the field QueryTimeEntries sorts its results by.
+type TimeEntrySortKey int
+
+// Sort keys supported by TimeEntryQuery.
+const (
+	SortByStart TimeEntrySortKey = iota
+	SortByStop
+	SortByDuration
+	SortByDescription
+	SortByProject
+)
+
+// TimeEntryQuery describes a time entries lookup. The date range is sent to
+// the server and every other filter is applied locally on the results.
+type TimeEntryQuery struct {
+	start       time.Time
+	end         time.Time
+	workspaceID int
+	projectIDs  map[int]bool
+	tagsAny     []string
+	tagsAll     []string
+	tagsNone    []string
+	description *regexp.Regexp
+	billable    *bool
+	running     *bool
+	minDuration time.Duration
+	maxDuration time.Duration
+	sortKey     TimeEntrySortKey
+	sortDesc    bool
+	now         func() time.Time
+}
+
+// NewTimeEntryQuery returns a query matching every time entry between start and end.
+func NewTimeEntryQuery(start, end time.Time) *TimeEntryQuery {
+	return &TimeEntryQuery{
+		start: start,
+		end:   end,
+		now:   time.Now,
+	}
+}
+
+// Workspace limits the query to entries of the given workspace.
+func (q *TimeEntryQuery) Workspace(id int) *TimeEntryQuery {
+	q.workspaceID = id
+	return q
+}
+
+// Projects limits the query to entries of any of the given projects.
+func (q *TimeEntryQuery) Projects(ids ...int) *TimeEntryQuery {
+	if q.projectIDs == nil {
+		q.projectIDs = map[int]bool{}
+	}
+	for _, id := range ids {
+		q.projectIDs[id] = true
+	}
+	return q
+}
+
+// TagsAny limits the query to entries having at least one of the given tags.
+func (q *TimeEntryQuery) TagsAny(tags ...string) *TimeEntryQuery {
+	q.tagsAny = append(q.tagsAny, tags...)
+	return q
+}
+
+// TagsAll limits the query to entries having every one of the given tags.
+func (q *TimeEntryQuery) TagsAll(tags ...string) *TimeEntryQuery {
+	q.tagsAll = append(q.tagsAll, tags...)
+	return q
+}
+
+// TagsNone limits the query to entries having none of the given tags.
+func (q *TimeEntryQuery) TagsNone(tags ...string) *TimeEntryQuery {
+	q.tagsNone = append(q.tagsNone, tags...)
+	return q
+}
+
+// DescriptionMatches limits the query to entries whose description matches re.
+func (q *TimeEntryQuery) DescriptionMatches(re *regexp.Regexp) *TimeEntryQuery {
+	q.description = re
+	return q
+}
+
+// Billable limits the query to billable or non-billable entries.
+func (q *TimeEntryQuery) Billable(billable bool) *TimeEntryQuery {
+	q.billable = &billable
+	return q
+}
+
+// Running limits the query to the running entry.
+func (q *TimeEntryQuery) Running() *TimeEntryQuery {
+	running := true
+	q.running = &running
+	return q
+}
+
+// Stopped limits the query to stopped entries.
+func (q *TimeEntryQuery) Stopped() *TimeEntryQuery {
+	running := false
+	q.running = &running
+	return q
+}
+
+// MinDuration limits the query to entries lasting at least d.
+// Running entries are measured up to now.
+func (q *TimeEntryQuery) MinDuration(d time.Duration) *TimeEntryQuery {
+	q.minDuration = d
+	return q
+}
+
+// MaxDuration limits the query to entries lasting at most d.
+// Running entries are measured up to now.
+func (q *TimeEntryQuery) MaxDuration(d time.Duration) *TimeEntryQuery {
+	q.maxDuration = d
+	return q
+}
+
+// SortBy sets the order of the results. Entries are sorted by start by default.
+func (q *TimeEntryQuery) SortBy(key TimeEntrySortKey, desc bool) *TimeEntryQuery {
+	q.sortKey = key
+	q.sortDesc = desc
+	return q
+}
+
+// Match reports whether the time entry satisfies every local filter of the query.
+func (q *TimeEntryQuery) Match(te TimeEntry) bool {
+	if q.workspaceID != 0 && te.Wid != q.workspaceID {
+		return false
+	}
+	if q.projectIDs != nil && !q.projectIDs[te.Pid] {
+		return false
+	}
+	if len(q.tagsAny) > 0 && !hasAnyTag(te.Tags, q.tagsAny) {
+		return false
+	}
+	for _, tag := range q.tagsAll {
+		if !hasAnyTag(te.Tags, []string{tag}) {
+			return false
+		}
+	}
+	if hasAnyTag(te.Tags, q.tagsNone) {
+		return false
+	}
+	if q.description != nil && !q.description.MatchString(te.Description) {
+		return false
+	}
+	if q.billable != nil && te.Billable != *q.billable {
+		return false
+	}
+	if q.running != nil && te.IsRunning() != *q.running {
+		return false
+	}
+
+	d := te.Elapsed(q.now())
+	if q.minDuration != 0 && d < q.minDuration {
+		return false
+	}
+	if q.maxDuration != 0 && d > q.maxDuration {
+		return false
+	}
+
+	return true
+}
+
+// Apply filters and sorts the time entries according to the query.
+func (q *TimeEntryQuery) Apply(entries []TimeEntry) []TimeEntry {
+	result := []TimeEntry{}
+	for _, te := range entries {
+		if q.Match(te) {
+			result = append(result, te)
+		}
+	}
+
+	now := q.now()
+	less := func(a, b TimeEntry) bool {
+		switch q.sortKey {
+		case SortByStop:
+			return a.EffectiveStop(now).Before(b.EffectiveStop(now))
+		case SortByDuration:
+			return a.Elapsed(now) < b.Elapsed(now)
+		case SortByDescription:
+			return strings.ToLower(a.Description) < strings.ToLower(b.Description)
+		case SortByProject:
+			return a.Pid < b.Pid
+		default:
+			return a.Start.Before(b.Start)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if q.sortDesc {
+			return less(result[j], result[i])
+		}
+		return less(result[i], result[j])
+	})
+
+	return result
+}
+
+// QueryTimeEntries will retrive the time entries matching the query.
+func (c *Client) QueryTimeEntries(ctx context.Context, q *TimeEntryQuery) (*[]TimeEntry, error) {
+	entries, err := c.GetTimeEntries(ctx, q.start, q.end)
+	if err != nil {
+		return nil, err
+	}
+
+	result := q.Apply(*entries)
+	return &result, nil
+}
+
+func hasAnyTag(tags []string, want []string) bool {
+	for _, tag := range tags {
+		for _, w := range want {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
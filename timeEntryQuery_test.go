@@ -0,0 +1,107 @@
+package toggl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func getTestQueryTimeEntries() []TimeEntry {
+	base := time.Date(2018, 4, 12, 9, 0, 0, 0, time.UTC)
+	return []TimeEntry{
+		{ID: 1, Wid: 3278506, Pid: 10, Description: "weekly meeting", Billable: true, Start: base.Add(2 * time.Hour), Duration: 1800, Tags: []string{"meeting"}},
+		{ID: 2, Wid: 3278506, Pid: 10, Description: "short sync meeting", Billable: true, Start: base, Duration: 120, Tags: []string{"meeting"}},
+		{ID: 3, Wid: 3278506, Pid: 20, Description: "coding", Billable: false, Start: base.Add(time.Hour), Duration: 3600, Tags: []string{"dev"}},
+		{ID: 4, Wid: 3278506, Pid: 10, Description: "planning meeting", Billable: true, Start: base.Add(3 * time.Hour), Duration: 900, Tags: []string{"meeting", "internal"}},
+		{ID: 5, Wid: 9999999, Pid: 10, Description: "other meeting", Billable: true, Start: base.Add(4 * time.Hour), Duration: 900, Tags: []string{"meeting"}},
+		{ID: 6, Wid: 3278506, Pid: 10, Description: "running meeting", Billable: true, Start: base.Add(5 * time.Hour), Duration: -int(base.Add(5 * time.Hour).Unix()), Tags: []string{"meeting"}},
+	}
+}
+
+func TestQueryTimeEntries(t *testing.T) {
+	expectedURL := "/api/v8/time_entries"
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if !strings.HasPrefix(req.URL.Path, expectedURL) {
+			return nil, fmt.Errorf("Expected URL '%s', got %s", expectedURL, req.URL.Path)
+		}
+
+		b, err := json.Marshal(getTestQueryTimeEntries())
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+
+	})
+	api := New("test", OptionHTTPClient(client))
+
+	start := time.Date(2018, 4, 12, 0, 0, 0, 0, time.UTC)
+	q := NewTimeEntryQuery(start, start.Add(24*time.Hour)).
+		Workspace(3278506).
+		Projects(10).
+		TagsAny("meeting").
+		TagsNone("internal").
+		DescriptionMatches(regexp.MustCompile("meeting$")).
+		Billable(true).
+		Stopped().
+		MinDuration(5*time.Minute).
+		SortBy(SortByDuration, true)
+
+	timeEntries, err := api.QueryTimeEntries(context.Background(), q)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	ids := []int{}
+	for _, te := range *timeEntries {
+		ids = append(ids, te.ID)
+	}
+	if !reflect.DeepEqual([]int{1}, ids) {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+}
+
+func TestTimeEntryQueryApply(t *testing.T) {
+	now := time.Date(2018, 4, 12, 14, 30, 0, 0, time.UTC)
+	start := time.Date(2018, 4, 12, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    *TimeEntryQuery
+		expected []int
+	}{
+		{"default sorts by start", NewTimeEntryQuery(start, now), []int{2, 3, 1, 4, 5, 6}},
+		{"tags all", NewTimeEntryQuery(start, now).TagsAll("meeting", "internal"), []int{4}},
+		{"running", NewTimeEntryQuery(start, now).Running(), []int{6}},
+		{"max duration includes running time", NewTimeEntryQuery(start, now).MaxDuration(20 * time.Minute), []int{2, 4, 5}},
+		{"min duration includes running time", NewTimeEntryQuery(start, now).MinDuration(30*time.Minute).SortBy(SortByDuration, false), []int{1, 6, 3}},
+		{"sort by description", NewTimeEntryQuery(start, now).Projects(20, 10).SortBy(SortByDescription, false), []int{3, 5, 4, 6, 2, 1}},
+		{"not billable", NewTimeEntryQuery(start, now).Billable(false), []int{3}},
+	}
+
+	for _, test := range tests {
+		test.query.now = func() time.Time { return now }
+
+		ids := []int{}
+		for _, te := range test.query.Apply(getTestQueryTimeEntries()) {
+			ids = append(ids, te.ID)
+		}
+		if !reflect.DeepEqual(test.expected, ids) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, ids)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package toggl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TimeEntriesRangeOptions configures how GetTimeEntriesRange splits a range.
+type TimeEntriesRangeOptions struct {
+	// Window is the length of each request window. Defaults to 7 days.
+	Window time.Duration
+	// MinWindow is the shortest window a truncated window is subdivided into.
+	// Defaults to 1 hour.
+	MinWindow time.Duration
+	// Limit is the number of entries the server returns at most per request.
+	// A window returning Limit entries or more is considered truncated.
+	// Defaults to 1000.
+	Limit int
+	// Concurrency is the number of requests sent in parallel. Defaults to 1.
+	Concurrency int
+}
+
+func (o *TimeEntriesRangeOptions) withDefaults() TimeEntriesRangeOptions {
+	opts := TimeEntriesRangeOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.Window <= 0 {
+		opts.Window = 7 * 24 * time.Hour
+	}
+	if opts.MinWindow <= 0 {
+		opts.MinWindow = time.Hour
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 1000
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	return opts
+}
+
+// GetTimeEntriesRange will retrive time entries in a time range of any length.
+// The range is split into windows, and windows hitting the server limit are
+// subdivided until they are not truncated anymore. The result is deduplicated
+// by ID and sorted by start.
+func (c *Client) GetTimeEntriesRange(ctx context.Context, start, end time.Time, options *TimeEntriesRangeOptions) (*[]TimeEntry, error) {
+	opts := options.withDefaults()
+	if !start.Before(end) {
+		return nil, fmt.Errorf("invalid time range: start %s is not before end %s", start, end)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		seen     = map[int]TimeEntry{}
+	)
+	sem := make(chan struct{}, opts.Concurrency)
+
+	for ws := start; ws.Before(end); ws = ws.Add(opts.Window) {
+		we := ws.Add(opts.Window)
+		if we.After(end) {
+			we = end
+		}
+
+		wg.Add(1)
+		go func(ws, we time.Time) {
+			defer wg.Done()
+
+			entries, err := c.fetchTimeEntriesWindow(ctx, ws, we, opts, sem)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			for _, te := range entries {
+				seen[te.ID] = te
+			}
+		}(ws, we)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	result := make([]TimeEntry, 0, len(seen))
+	for _, te := range seen {
+		result = append(result, te)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Start.Equal(result[j].Start) {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].Start.Before(result[j].Start)
+	})
+
+	return &result, nil
+}
+
+func (c *Client) fetchTimeEntriesWindow(ctx context.Context, start, end time.Time, opts TimeEntriesRangeOptions, sem chan struct{}) ([]TimeEntry, error) {
+	select {
+	case sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	entries, err := c.GetTimeEntries(ctx, start, end)
+	<-sem
+	if err != nil {
+		return nil, err
+	}
+
+	if len(*entries) < opts.Limit {
+		return *entries, nil
+	}
+	if end.Sub(start) <= opts.MinWindow {
+		return nil, fmt.Errorf("time entries between %s and %s exceed the server limit of %d", start.Format(time.RFC3339), end.Format(time.RFC3339), opts.Limit)
+	}
+
+	c.Debugf("time entries between %s and %s are truncated, subdividing", start, end)
+	mid := start.Add(end.Sub(start) / 2)
+	first, err := c.fetchTimeEntriesWindow(ctx, start, mid, opts, sem)
+	if err != nil {
+		return nil, err
+	}
+	second, err := c.fetchTimeEntriesWindow(ctx, mid, end, opts, sem)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(first, second...), nil
+}
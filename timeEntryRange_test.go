@@ -0,0 +1,105 @@
+package toggl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newRangeMockClient(entries []TimeEntry, limit int) *http.Client {
+	expectedURL := "/api/v8/time_entries"
+
+	return newMockClient(func(req *http.Request) (*http.Response, error) {
+		if !strings.HasPrefix(req.URL.Path, expectedURL) {
+			return nil, fmt.Errorf("Expected URL '%s', got %s", expectedURL, req.URL.Path)
+		}
+
+		start, err := time.Parse(time.RFC3339, req.URL.Query().Get("start_date"))
+		if err != nil {
+			return nil, err
+		}
+		end, err := time.Parse(time.RFC3339, req.URL.Query().Get("end_date"))
+		if err != nil {
+			return nil, err
+		}
+
+		result := []TimeEntry{}
+		for _, te := range entries {
+			if len(result) == limit {
+				break
+			}
+			if !te.Start.Before(start) && !te.Start.After(end) {
+				result = append(result, te)
+			}
+		}
+
+		b, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+	})
+}
+
+func TestGetTimeEntriesRange(t *testing.T) {
+	start := time.Date(2018, 4, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(72 * time.Hour)
+
+	entries := []TimeEntry{}
+	for i := 0; i < 144; i++ {
+		entries = append(entries, TimeEntry{
+			ID:       i + 1,
+			Start:    start.Add(time.Duration(i) * 30 * time.Minute),
+			Duration: 1800,
+		})
+	}
+
+	api := New("test", OptionHTTPClient(newRangeMockClient(entries, 20)))
+
+	timeEntries, err := api.GetTimeEntriesRange(context.Background(), start, end, &TimeEntriesRangeOptions{
+		Window:      24 * time.Hour,
+		Limit:       20,
+		Concurrency: 3,
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	if len(*timeEntries) != len(entries) {
+		t.Fatalf("Expected %d entries, got %d", len(entries), len(*timeEntries))
+	}
+	for i, te := range *timeEntries {
+		if te.ID != i+1 {
+			t.Fatalf("Expected entry %d at position %d, got %d", i+1, i, te.ID)
+		}
+	}
+}
+
+func TestGetTimeEntriesRangeTruncated(t *testing.T) {
+	start := time.Date(2018, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	entries := []TimeEntry{}
+	for i := 0; i < 10; i++ {
+		entries = append(entries, TimeEntry{ID: i + 1, Start: start.Add(time.Minute)})
+	}
+
+	api := New("test", OptionHTTPClient(newRangeMockClient(entries, 5)))
+
+	_, err := api.GetTimeEntriesRange(context.Background(), start, start.Add(24*time.Hour), &TimeEntriesRangeOptions{
+		Limit: 5,
+	})
+	if err == nil {
+		t.Fatal("Expected an error for a window that cannot be subdivided")
+	}
+}
@@ -0,0 +1,127 @@
+package toggl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TimeEntryTemplate holds the fields of the second part of a split time entry.
+// The nil fields are copied from the split time entry.
+type TimeEntryTemplate struct {
+	Description *string
+	Tags        *[]string
+	Pid         *int
+	Tid         *int
+	Billable    *bool
+}
+
+func (t TimeEntryTemplate) options(timeEntry *TimeEntry) TimeEntryOptions {
+	opts := TimeEntryOptions{
+		Description: timeEntry.Description,
+		Tags:        timeEntry.Tags,
+		Wid:         timeEntry.Wid,
+		Pid:         timeEntry.Pid,
+		Billable:    timeEntry.Billable,
+	}
+	if t.Description != nil {
+		opts.Description = *t.Description
+	}
+	if t.Tags != nil {
+		opts.Tags = *t.Tags
+	}
+	if t.Pid != nil {
+		opts.Pid = *t.Pid
+	}
+	if t.Tid != nil {
+		opts.Tid = *t.Tid
+	}
+	if t.Billable != nil {
+		opts.Billable = *t.Billable
+	}
+	return opts
+}
+
+// SplitTimeEntry cuts the time entry in two at the given time. The time entry
+// is stopped at that time, and the second part is created from it up to the
+// original stop, or started from it when the time entry is running. When the
+// second part cannot be created, the time entry is restored.
+// It returns both parts.
+func (c *Client) SplitTimeEntry(ctx context.Context, id int, at time.Time, second TimeEntryTemplate) (*TimeEntry, *TimeEntry, error) {
+	timeEntry, err := c.GetTimeEntry(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	running := timeEntry.IsRunning()
+	stop := timeEntry.EffectiveStop(time.Now())
+	if !at.After(timeEntry.Start) || !at.Before(stop) {
+		return nil, nil, fmt.Errorf("split time %s is not within time entry %d", at.Format(time.RFC3339), id)
+	}
+
+	opts := second.options(timeEntry)
+	opts.Start = at
+	if running {
+		err = opts.validate(true)
+	} else {
+		opts.Stop = stop
+		err = opts.validate(false)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	first, err := c.PatchTimeEntry(ctx, id, TimeEntryUpdate{
+		Stop:     Time(at),
+		Duration: Int(int(at.Sub(timeEntry.Start) / time.Second)),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rest *TimeEntry
+	if running {
+		rest, err = c.StartTimeEntryWithOptions(ctx, opts)
+	} else {
+		rest, err = c.CreateTimeEntryWithOptions(ctx, opts)
+	}
+	if err != nil {
+		if rollbackErr := c.restoreTimeEntry(ctx, timeEntry); rollbackErr != nil {
+			return nil, nil, fmt.Errorf("splitting time entry %d: %s, and restoring it failed: %s", id, err, rollbackErr)
+		}
+		return nil, nil, fmt.Errorf("splitting time entry %d: %s", id, err)
+	}
+
+	return first, rest, nil
+}
+
+// restoreTimeEntry puts back the stop and duration of the time entry.
+func (c *Client) restoreTimeEntry(ctx context.Context, timeEntry *TimeEntry) error {
+	if !timeEntry.IsRunning() {
+		_, err := c.PatchTimeEntry(ctx, timeEntry.ID, TimeEntryUpdate{
+			Stop:     Time(timeEntry.EffectiveStop(time.Now())),
+			Duration: Int(timeEntry.Duration),
+		})
+		return err
+	}
+
+	// A running entry has no stop, which the update cannot send.
+	spath := fmt.Sprintf("v8/time_entries/%d", timeEntry.ID)
+	response := &TimeEntryResponse{}
+
+	params := struct {
+		TimeEntry struct {
+			Stop     *string `json:"stop"`
+			Duration int     `json:"duration"`
+		} `json:"time_entry"`
+	}{}
+	params.TimeEntry.Duration = timeEntry.Duration
+	j, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	return c.put(ctx, spath, bytes.NewBuffer(j), response)
+}
@@ -0,0 +1,76 @@
+package toggl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplitTimeEntry(t *testing.T) {
+	start := time.Date(2018, 4, 12, 9, 0, 0, 0, time.UTC)
+	at := start.Add(time.Hour)
+	server := newFakeTimeEntryServer(TimeEntry{ID: 1, Wid: 1, Pid: 10, Description: "coding", Tags: []string{"dev"}, Start: start, Stop: start.Add(3 * time.Hour), Duration: 3 * 3600})
+	api := New("test", OptionHTTPClient(server.client()))
+
+	first, second, err := api.SplitTimeEntry(context.Background(), 1, at, TimeEntryTemplate{Description: String("meeting"), Tags: Strings()})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if first.ID != 1 || first.Duration != 3600 || !first.Stop.Equal(at) {
+		t.Fatalf("Unexpected first part %+v", first)
+	}
+
+	expected := TimeEntry{ID: 100, Wid: 1, Pid: 10, Description: "meeting", Tags: []string{}, Start: at, Stop: start.Add(3 * time.Hour), Duration: 2 * 3600}
+	got := server.entries[second.ID]
+	got.Start, got.Stop = got.Start.UTC(), got.Stop.UTC()
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected second part %+v, got %+v", expected, got)
+	}
+
+	if _, _, err := api.SplitTimeEntry(context.Background(), 1, start.Add(2*time.Hour), TimeEntryTemplate{}); err == nil {
+		t.Fatal(errors.New("Expected an error for a split time outside the time entry"))
+	}
+}
+
+func TestSplitRunningTimeEntry(t *testing.T) {
+	start := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	at := start.Add(30 * time.Minute)
+	server := newFakeTimeEntryServer(TimeEntry{ID: 1, Wid: 1, Description: "coding", Start: start, Duration: -int(start.Unix())})
+	api := New("test", OptionHTTPClient(server.client()))
+
+	_, second, err := api.SplitTimeEntry(context.Background(), 1, at, TimeEntryTemplate{Pid: Int(20)})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if server.entries[1].IsRunning() || server.entries[1].Duration != 1800 {
+		t.Fatalf("Expected the time entry to be stopped, got %+v", server.entries[1])
+	}
+	rest := server.entries[second.ID]
+	if !rest.IsRunning() || rest.Pid != 20 || rest.Description != "coding" || rest.Duration != -int(at.Unix()) {
+		t.Fatalf("Expected the second part to be running, got %+v", rest)
+	}
+}
+
+func TestSplitTimeEntryRollback(t *testing.T) {
+	start := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	server := newFakeTimeEntryServer(TimeEntry{ID: 1, Wid: 1, Description: "coding", Start: start, Duration: -int(start.Unix())})
+	inner := server.client()
+	api := New("test", OptionHTTPClient(newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method == "POST" {
+			return nil, errors.New("network is unreachable")
+		}
+		return inner.Transport.RoundTrip(req)
+	})))
+
+	if _, _, err := api.SplitTimeEntry(context.Background(), 1, start.Add(30*time.Minute), TimeEntryTemplate{}); err == nil {
+		t.Fatal(errors.New("Expected an error when the second part cannot be created"))
+	}
+	if !server.entries[1].IsRunning() || server.entries[1].Duration != -int(start.Unix()) || len(server.entries) != 1 {
+		t.Fatalf("Expected the time entry to be restored, got %+v", server.entries)
+	}
+}
@@ -69,6 +69,90 @@ func TestRunningTimeEntry(t *testing.T) {
 	}
 }
 
+func TestRunningTimeEntryNone(t *testing.T) {
+	expectedURL := "/api/v8/time_entries/current"
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if !strings.HasPrefix(req.URL.Path, expectedURL) {
+			return nil, fmt.Errorf("Expected URL '%s', got %s", expectedURL, req.URL.Path)
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"data":null}`)),
+		}, nil
+
+	})
+	api := New("test", OptionHTTPClient(client))
+
+	timeEntry, err := api.GetRunningTimeEntry(context.Background())
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if timeEntry != nil {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+}
+
+func TestTimeEntryDurations(t *testing.T) {
+	start := time.Date(2018, 4, 12, 7, 0, 0, 0, time.UTC)
+	now := start.Add(90 * time.Minute)
+
+	tests := []struct {
+		name          string
+		timeEntry     TimeEntry
+		running       bool
+		durationValue time.Duration
+		elapsed       time.Duration
+		effectiveStop time.Time
+	}{
+		{
+			name:          "stopped",
+			timeEntry:     TimeEntry{Start: start, Stop: start.Add(30 * time.Minute), Duration: 1800},
+			durationValue: 30 * time.Minute,
+			elapsed:       30 * time.Minute,
+			effectiveStop: start.Add(30 * time.Minute),
+		},
+		{
+			name:          "stopped without stop",
+			timeEntry:     TimeEntry{Start: start, Duration: 600, Duronly: true},
+			durationValue: 10 * time.Minute,
+			elapsed:       10 * time.Minute,
+			effectiveStop: start.Add(10 * time.Minute),
+		},
+		{
+			name:          "running",
+			timeEntry:     TimeEntry{Start: start, Duration: -int(start.Unix())},
+			running:       true,
+			elapsed:       90 * time.Minute,
+			effectiveStop: now,
+		},
+		{
+			name:          "running without start",
+			timeEntry:     TimeEntry{Duration: -int(start.Unix())},
+			running:       true,
+			elapsed:       90 * time.Minute,
+			effectiveStop: now,
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.timeEntry.IsRunning(); got != test.running {
+			t.Errorf("%s: IsRunning expected %v, got %v", test.name, test.running, got)
+		}
+		if got := test.timeEntry.DurationValue(); got != test.durationValue {
+			t.Errorf("%s: DurationValue expected %s, got %s", test.name, test.durationValue, got)
+		}
+		if got := test.timeEntry.Elapsed(now); got != test.elapsed {
+			t.Errorf("%s: Elapsed expected %s, got %s", test.name, test.elapsed, got)
+		}
+		if got := test.timeEntry.EffectiveStop(now); !got.Equal(test.effectiveStop) {
+			t.Errorf("%s: EffectiveStop expected %s, got %s", test.name, test.effectiveStop, got)
+		}
+	}
+}
+
 func TestGetTimeEntry(t *testing.T) {
 	expected := getTestTimeEntry()
 	expectedURL := "/api/v8/time_entries/1111111111"
@@ -109,11 +193,19 @@ func TestGetTimeEntry(t *testing.T) {
 func TestCreateTimeEntry(t *testing.T) {
 	expected := getTestTimeEntry()
 	expectedURL := "/api/v8/time_entries"
+	expectedStart := `"start":"2018-04-12T07:49:00Z"`
 
 	client := newMockClient(func(req *http.Request) (*http.Response, error) {
 		if !strings.HasPrefix(req.URL.Path, expectedURL) {
 			return nil, fmt.Errorf("Expected URL '%s', got %s", expectedURL, req.URL.Path)
 		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.Contains(string(body), expectedStart) {
+			return nil, fmt.Errorf("Expected start '%s', got %s", expectedStart, body)
+		}
 
 		b, err := json.Marshal(struct {
 			Data TimeEntry `json:"data"`
@@ -277,3 +369,216 @@ func TestStopTimeEntry(t *testing.T) {
 		return
 	}
 }
+
+func TestStopTimeEntryAt(t *testing.T) {
+	expectedURL := "/api/v8/time_entries/1111111111"
+	expectedBody := `{"time_entry":{"stop":"2018-04-12T08:19:00Z","duration":1800}}`
+	stop := time.Date(2018, 4, 12, 8, 19, 0, 0, time.UTC)
+	expected := getTestTimeEntry()
+	expected.Stop = stop
+	expected.Duration = 1800
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != expectedURL {
+			return nil, fmt.Errorf("Expected URL '%s', got %s", expectedURL, req.URL.Path)
+		}
+
+		data := getTestTimeEntry()
+		if req.Method == "PUT" {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			if string(body) != expectedBody {
+				return nil, fmt.Errorf("Expected body '%s', got %s", expectedBody, body)
+			}
+			data = expected
+		}
+
+		b, err := json.Marshal(struct {
+			Data TimeEntry `json:"data"`
+		}{
+			Data: data,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+
+	})
+	api := New("test", OptionHTTPClient(client))
+
+	timeEntry, err := api.StopTimeEntryAt(context.Background(), 1111111111, stop)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if !reflect.DeepEqual(expected, *timeEntry) {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+
+	_, err = api.StopTimeEntryAt(context.Background(), 1111111111, getTestTimeEntry().Start.Add(-time.Minute))
+	if err == nil {
+		t.Fatal(errors.New("Expected an error for a stop time before start"))
+	}
+}
+
+func TestStopCurrent(t *testing.T) {
+	expected := getTestTimeEntry()
+	requested := []string{}
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		requested = append(requested, req.Method+" "+req.URL.Path)
+
+		b, err := json.Marshal(struct {
+			Data TimeEntry `json:"data"`
+		}{
+			Data: getTestTimeEntry(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+
+	})
+	api := New("test", OptionHTTPClient(client))
+
+	timeEntry, err := api.StopCurrent(context.Background())
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if !reflect.DeepEqual(expected, *timeEntry) {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+
+	expectedRequests := []string{
+		"GET /api/v8/time_entries/current",
+		"PUT /api/v8/time_entries/1111111111/stop",
+	}
+	if !reflect.DeepEqual(expectedRequests, requested) {
+		t.Fatalf("Expected requests %v, got %v", expectedRequests, requested)
+	}
+}
+
+func TestContinueTimeEntry(t *testing.T) {
+	expected := getTestTimeEntry()
+	expectedBody := `{"time_entry":{"description":"toggl test","tags":["fun"],"pid":123456789,"wid":3278506,"billable":true,"created_with":"otms61/toggl"}}`
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		data := getTestTimeEntry()
+		data.Billable = true
+
+		switch req.Method + " " + req.URL.Path {
+		case "GET /api/v8/time_entries/1111111111":
+		case "POST /api/v8/time_entries/start":
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			if string(body) != expectedBody {
+				return nil, fmt.Errorf("Expected body '%s', got %s", expectedBody, body)
+			}
+			data = expected
+		default:
+			return nil, fmt.Errorf("Unexpected request %s %s", req.Method, req.URL.Path)
+		}
+
+		b, err := json.Marshal(struct {
+			Data TimeEntry `json:"data"`
+		}{
+			Data: data,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+
+	})
+	api := New("test", OptionHTTPClient(client))
+
+	timeEntry, err := api.ContinueTimeEntry(context.Background(), 1111111111)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if !reflect.DeepEqual(expected, *timeEntry) {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+}
+
+func TestPatchTimeEntry(t *testing.T) {
+	expected := getTestTimeEntry()
+	expectedURL := "/api/v8/time_entries/1111111111"
+
+	tests := []struct {
+		update       TimeEntryUpdate
+		expectedBody string
+	}{
+		{
+			update:       TimeEntryUpdate{Description: String("toggl test")},
+			expectedBody: `{"time_entry":{"description":"toggl test"}}`,
+		},
+		{
+			update:       TimeEntryUpdate{Tags: Strings(), Pid: Int(0)},
+			expectedBody: `{"time_entry":{"tags":[],"pid":0}}`,
+		},
+		{
+			update:       TimeEntryUpdate{Start: Time(time.Date(2018, 4, 12, 7, 49, 0, 0, time.UTC)), Duration: Int(30)},
+			expectedBody: `{"time_entry":{"start":"2018-04-12T07:49:00Z","duration":30}}`,
+		},
+	}
+
+	for _, test := range tests {
+		client := newMockClient(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, expectedURL) {
+				return nil, fmt.Errorf("Expected URL '%s', got %s", expectedURL, req.URL.Path)
+			}
+
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			if string(body) != test.expectedBody {
+				return nil, fmt.Errorf("Expected body '%s', got %s", test.expectedBody, body)
+			}
+
+			b, err := json.Marshal(struct {
+				Data TimeEntry `json:"data"`
+			}{
+				Data: getTestTimeEntry(),
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+
+		})
+		api := New("test", OptionHTTPClient(client))
+
+		timeEntry, err := api.PatchTimeEntry(context.Background(), 1111111111, test.update)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if !reflect.DeepEqual(expected, *timeEntry) {
+			t.Fatal(errors.New("Response is incorrect"))
+		}
+	}
+}
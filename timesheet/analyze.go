@@ -0,0 +1,219 @@
+package timesheet
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+// FindingKind is the kind of issue reported by Analyze.
+type FindingKind string
+
+// Issues reported by Analyze.
+const (
+	FindingOverlap            FindingKind = "overlap"
+	FindingGap                FindingKind = "gap"
+	FindingMissingProject     FindingKind = "missing_project"
+	FindingMissingDescription FindingKind = "missing_description"
+	FindingLongRunning        FindingKind = "long_running"
+)
+
+// Finding is an issue found in time entries.
+type Finding struct {
+	Kind FindingKind
+	// Entries are the time entries involved, none for gaps.
+	Entries []toggl.TimeEntry
+	// Start and End bound the time the finding is about.
+	Start   time.Time
+	End     time.Time
+	Message string
+}
+
+// AnalyzeOptions configures Analyze.
+type AnalyzeOptions struct {
+	// Location of the working hours. Defaults to time.Local.
+	Location *time.Location
+	// WorkDays are the days gaps are looked for. Defaults to monday to friday.
+	WorkDays []time.Weekday
+	// WorkStart and WorkEnd are the working hours, as wall clock times from
+	// midnight in Location. They default to 9:00 and 18:00.
+	WorkStart time.Duration
+	WorkEnd   time.Duration
+	// MinGap is the shortest gap reported. Defaults to 15 minutes.
+	MinGap time.Duration
+	// MaxRunning is the longest a running entry may run before being reported. Defaults to 10 hours.
+	MaxRunning time.Duration
+	// Now ends the running entries. Defaults to time.Now.
+	Now func() time.Time
+}
+
+func (o *AnalyzeOptions) withDefaults() AnalyzeOptions {
+	opts := AnalyzeOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	if opts.WorkDays == nil {
+		opts.WorkDays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	}
+	if opts.WorkStart == 0 && opts.WorkEnd == 0 {
+		opts.WorkStart = 9 * time.Hour
+		opts.WorkEnd = 18 * time.Hour
+	}
+	if opts.MinGap == 0 {
+		opts.MinGap = 15 * time.Minute
+	}
+	if opts.MaxRunning == 0 {
+		opts.MaxRunning = 10 * time.Hour
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return opts
+}
+
+// Analyze reports overlapping entries, gaps within the working hours of the
+// work days from the first to the last entry, entries without project or
+// description and entries running for too long. Findings are sorted by start.
+func Analyze(timeEntries []toggl.TimeEntry, options *AnalyzeOptions) []Finding {
+	opts := options.withDefaults()
+	now := opts.Now()
+
+	entries := append([]toggl.TimeEntry{}, timeEntries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Start.Before(entries[j].Start)
+	})
+
+	findings := []Finding{}
+	for _, te := range entries {
+		stop := te.EffectiveStop(now)
+		if te.Pid == 0 {
+			findings = append(findings, Finding{
+				Kind:    FindingMissingProject,
+				Entries: []toggl.TimeEntry{te},
+				Start:   te.Start,
+				End:     stop,
+				Message: fmt.Sprintf("time entry %d has no project", te.ID),
+			})
+		}
+		if te.Description == "" {
+			findings = append(findings, Finding{
+				Kind:    FindingMissingDescription,
+				Entries: []toggl.TimeEntry{te},
+				Start:   te.Start,
+				End:     stop,
+				Message: fmt.Sprintf("time entry %d has no description", te.ID),
+			})
+		}
+		if te.IsRunning() && te.Elapsed(now) > opts.MaxRunning {
+			findings = append(findings, Finding{
+				Kind:    FindingLongRunning,
+				Entries: []toggl.TimeEntry{te},
+				Start:   te.Start,
+				End:     stop,
+				Message: fmt.Sprintf("time entry %d has been running for %s", te.ID, te.Elapsed(now).Round(time.Minute)),
+			})
+		}
+	}
+
+	findings = append(findings, overlaps(entries, now)...)
+	findings = append(findings, gaps(entries, opts, now)...)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Start.Before(findings[j].Start)
+	})
+	return findings
+}
+
+// overlaps reports every pair of overlapping entries, entries being sorted by start.
+func overlaps(entries []toggl.TimeEntry, now time.Time) []Finding {
+	findings := []Finding{}
+	for i, a := range entries {
+		aStop := a.EffectiveStop(now)
+		for _, b := range entries[i+1:] {
+			if !b.Start.Before(aStop) {
+				break
+			}
+			end := b.EffectiveStop(now)
+			if aStop.Before(end) {
+				end = aStop
+			}
+			findings = append(findings, Finding{
+				Kind:    FindingOverlap,
+				Entries: []toggl.TimeEntry{a, b},
+				Start:   b.Start,
+				End:     end,
+				Message: fmt.Sprintf("time entries %d and %d overlap for %s", a.ID, b.ID, end.Sub(b.Start)),
+			})
+		}
+	}
+	return findings
+}
+
+// gaps reports the untracked time within the working hours, entries being sorted by start.
+func gaps(entries []toggl.TimeEntry, opts AnalyzeOptions, now time.Time) []Finding {
+	findings := []Finding{}
+	if len(entries) == 0 {
+		return findings
+	}
+
+	workDays := map[time.Weekday]bool{}
+	for _, d := range opts.WorkDays {
+		workDays[d] = true
+	}
+
+	first := midnight(entries[0].Start.In(opts.Location))
+	last := first
+	for _, te := range entries {
+		if day := midnight(te.EffectiveStop(now).In(opts.Location)); day.After(last) {
+			last = day
+		}
+	}
+
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if !workDays[day.Weekday()] {
+			continue
+		}
+		cursor := clockTime(day, opts.WorkStart)
+		end := clockTime(day, opts.WorkEnd)
+		// The rest of the current day is not a gap yet.
+		if end.After(now) {
+			end = now
+		}
+		report := func(to time.Time) {
+			if to.Sub(cursor) >= opts.MinGap {
+				findings = append(findings, Finding{
+					Kind:    FindingGap,
+					Start:   cursor,
+					End:     to,
+					Message: fmt.Sprintf("nothing tracked from %s to %s", cursor.Format("2006-01-02 15:04"), to.Format("15:04")),
+				})
+			}
+		}
+
+		for _, te := range entries {
+			if !cursor.Before(end) {
+				break
+			}
+			start, stop := te.Start.In(opts.Location), te.EffectiveStop(now).In(opts.Location)
+			if !stop.After(cursor) {
+				continue
+			}
+			if !start.Before(end) {
+				break
+			}
+			if start.After(cursor) {
+				report(start)
+			}
+			cursor = stop
+		}
+		if cursor.Before(end) {
+			report(end)
+		}
+	}
+	return findings
+}
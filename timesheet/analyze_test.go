@@ -0,0 +1,137 @@
+package timesheet
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+func TestAnalyze(t *testing.T) {
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2018, 4, day, hour, min, 0, 0, time.UTC)
+	}
+	stopped := func(id int, start, stop time.Time) toggl.TimeEntry {
+		return toggl.TimeEntry{ID: id, Pid: 10, Description: "work", Start: start, Stop: stop, Duration: int(stop.Sub(start).Seconds())}
+	}
+
+	// Thursday 12th and friday 13th, the 14th being a saturday.
+	timeEntries := []toggl.TimeEntry{
+		stopped(1, at(12, 9, 0), at(12, 11, 0)),
+		stopped(2, at(12, 10, 30), at(12, 12, 0)),
+		stopped(3, at(12, 12, 10), at(12, 18, 0)),
+		{ID: 4, Start: at(13, 8, 0), Duration: -int(at(13, 8, 0).Unix())},
+	}
+	opts := &AnalyzeOptions{
+		Location: time.UTC,
+		Now:      func() time.Time { return at(14, 9, 0) },
+	}
+
+	type finding struct {
+		Kind  FindingKind
+		IDs   []int
+		Start time.Time
+		End   time.Time
+	}
+	got := []finding{}
+	for _, f := range Analyze(timeEntries, opts) {
+		ids := []int{}
+		for _, te := range f.Entries {
+			ids = append(ids, te.ID)
+		}
+		if f.Message == "" {
+			t.Fatalf("Expected a message for %+v", f)
+		}
+		got = append(got, finding{f.Kind, ids, f.Start, f.End})
+	}
+
+	expected := []finding{
+		{FindingOverlap, []int{1, 2}, at(12, 10, 30), at(12, 11, 0)},
+		{FindingMissingProject, []int{4}, at(13, 8, 0), at(14, 9, 0)},
+		{FindingMissingDescription, []int{4}, at(13, 8, 0), at(14, 9, 0)},
+		{FindingLongRunning, []int{4}, at(13, 8, 0), at(14, 9, 0)},
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected findings %+v, got %+v", expected, got)
+	}
+
+	// The 10 minutes gap is reported with a lower threshold.
+	opts.MinGap = 5 * time.Minute
+	opts.MaxRunning = 48 * time.Hour
+	findings := Analyze(timeEntries[:3], opts)
+	if len(findings) != 2 || findings[1].Kind != FindingGap || !findings[1].Start.Equal(at(12, 12, 0)) || !findings[1].End.Equal(at(12, 12, 10)) {
+		t.Fatalf("Unexpected findings %+v", findings)
+	}
+}
+
+func TestAnalyzeGaps(t *testing.T) {
+	at := func(day, hour int) time.Time {
+		return time.Date(2018, 4, day, hour, 0, 0, 0, time.UTC)
+	}
+	timeEntries := []toggl.TimeEntry{
+		{ID: 1, Pid: 10, Description: "work", Start: at(12, 10), Stop: at(12, 12), Duration: 7200},
+		{ID: 2, Pid: 10, Description: "work", Start: at(16, 13), Stop: at(16, 14), Duration: 3600},
+	}
+	opts := &AnalyzeOptions{
+		Location:  time.UTC,
+		WorkStart: 9 * time.Hour,
+		WorkEnd:   17 * time.Hour,
+		Now:       func() time.Time { return at(16, 15) },
+	}
+
+	got := [][2]time.Time{}
+	for _, f := range Analyze(timeEntries, opts) {
+		if f.Kind != FindingGap {
+			t.Fatalf("Unexpected finding %+v", f)
+		}
+		got = append(got, [2]time.Time{f.Start, f.End})
+	}
+
+	// The weekend is skipped and the current day ends now.
+	expected := [][2]time.Time{
+		{at(12, 9), at(12, 10)},
+		{at(12, 12), at(12, 17)},
+		{at(13, 9), at(13, 17)},
+		{at(16, 9), at(16, 13)},
+		{at(16, 14), at(16, 15)},
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected gaps %v, got %v", expected, got)
+	}
+}
+
+func TestAnalyzeGapsDaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	// Clocks moved forward on 2018-03-25 in Berlin.
+	at := func(hour int) time.Time {
+		return time.Date(2018, 3, 25, hour, 0, 0, 0, berlin)
+	}
+	timeEntries := []toggl.TimeEntry{
+		{ID: 1, Pid: 10, Description: "work", Start: at(10), Stop: at(17), Duration: 7 * 3600},
+	}
+	opts := &AnalyzeOptions{
+		Location:  berlin,
+		WorkDays:  []time.Weekday{time.Sunday},
+		WorkStart: 9 * time.Hour,
+		WorkEnd:   18 * time.Hour,
+		Now:       func() time.Time { return at(23) },
+	}
+
+	got := [][2]time.Time{}
+	for _, f := range Analyze(timeEntries, opts) {
+		got = append(got, [2]time.Time{f.Start, f.End})
+	}
+	expected := [][2]time.Time{{at(9), at(10)}, {at(17), at(18)}}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected gaps %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if !expected[i][0].Equal(got[i][0]) || !expected[i][1].Equal(got[i][1]) {
+			t.Fatalf("Expected gaps %v, got %v", expected, got)
+		}
+	}
+}
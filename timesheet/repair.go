@@ -0,0 +1,231 @@
+package timesheet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+// RepairMode tells how Repair fixes matching time entries.
+type RepairMode int
+
+// Repair modes.
+const (
+	// RepairMerge merges overlapping and adjacent entries into the earliest one.
+	RepairMerge RepairMode = iota
+	// RepairTrim moves the start of overlapping entries to the stop of the previous one.
+	RepairTrim
+)
+
+// ActionKind is the API call of a repair Action.
+type ActionKind string
+
+// API calls of a repair plan.
+const (
+	ActionUpdate ActionKind = "update"
+	ActionDelete ActionKind = "delete"
+)
+
+// Action is an API call of a repair plan.
+type Action struct {
+	Kind ActionKind
+	ID   int
+	// Update is the partial update sent, for updates.
+	Update *toggl.TimeEntryUpdate
+	Reason string
+}
+
+// String describes the API call.
+func (a Action) String() string {
+	if a.Kind == ActionDelete {
+		return fmt.Sprintf("DELETE time_entries/%d (%s)", a.ID, a.Reason)
+	}
+	b, _ := json.Marshal(a.Update)
+	return fmt.Sprintf("PUT time_entries/%d %s (%s)", a.ID, b, a.Reason)
+}
+
+// Plan is the list of API calls repairing time entries, in execution order.
+type Plan struct {
+	Actions []Action
+}
+
+// String lists the API calls of the plan, one per line.
+func (p *Plan) String() string {
+	lines := []string{}
+	for _, a := range p.Actions {
+		lines = append(lines, a.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RepairAPI is the part of the client used to repair time entries.
+type RepairAPI interface {
+	GetTimeEntries(ctx context.Context, start, end time.Time) (*[]toggl.TimeEntry, error)
+	PatchTimeEntry(ctx context.Context, id int, update toggl.TimeEntryUpdate) (*toggl.TimeEntry, error)
+	DeleteTimeEntry(ctx context.Context, id int) error
+}
+
+// Execute runs the API calls in order, stopping at the first error.
+// It returns the number of calls done.
+func (p *Plan) Execute(ctx context.Context, api RepairAPI) (int, error) {
+	for i, a := range p.Actions {
+		var err error
+		switch a.Kind {
+		case ActionUpdate:
+			_, err = api.PatchTimeEntry(ctx, a.ID, *a.Update)
+		case ActionDelete:
+			err = api.DeleteTimeEntry(ctx, a.ID)
+		default:
+			err = fmt.Errorf("unknown action %q", a.Kind)
+		}
+		if err != nil {
+			return i, fmt.Errorf("%s: %s", a, err)
+		}
+	}
+	return len(p.Actions), nil
+}
+
+// RepairOptions configures the repair of time entries.
+type RepairOptions struct {
+	Mode RepairMode
+	// MaxGap is the longest gap between entries merged as adjacent. Defaults to 0,
+	// only merging entries stopping exactly when the next one starts. Merged gaps
+	// become tracked time, which the reason of the merge reports.
+	MaxGap time.Duration
+	// DryRun only plans the API calls.
+	DryRun bool
+}
+
+// PlanRepair plans the API calls fixing the time entries having the same
+// workspace, project, description, tags and billable flag which overlap,
+// or which are adjacent when merging. Running entries are left untouched.
+func PlanRepair(timeEntries []toggl.TimeEntry, options *RepairOptions) *Plan {
+	opts := RepairOptions{}
+	if options != nil {
+		opts = *options
+	}
+
+	entries := []toggl.TimeEntry{}
+	for _, te := range timeEntries {
+		if !te.IsRunning() {
+			entries = append(entries, te)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Start.Equal(entries[j].Start) {
+			return entries[i].ID < entries[j].ID
+		}
+		return entries[i].Start.Before(entries[j].Start)
+	})
+
+	type head struct {
+		entry toggl.TimeEntry
+		// stop is the latest stop of the entry and of the entries merged into it.
+		stop   time.Time
+		merged []int
+		// gap is the untracked time added by the merge.
+		gap time.Duration
+	}
+	heads := []*head{}
+	last := map[string]*head{}
+	plan := &Plan{Actions: []Action{}}
+
+	for _, te := range entries {
+		key := repairKey(te)
+		stop := te.EffectiveStop(time.Time{})
+		h, ok := last[key]
+
+		if ok && opts.Mode == RepairMerge && !te.Start.After(h.stop.Add(opts.MaxGap)) {
+			if te.Start.After(h.stop) {
+				h.gap += te.Start.Sub(h.stop)
+			}
+			if stop.After(h.stop) {
+				h.stop = stop
+			}
+			h.merged = append(h.merged, te.ID)
+			continue
+		}
+
+		if ok && opts.Mode == RepairTrim && te.Start.Before(h.stop) {
+			if !stop.After(h.stop) {
+				plan.Actions = append(plan.Actions, Action{
+					Kind:   ActionDelete,
+					ID:     te.ID,
+					Reason: fmt.Sprintf("within time entry %d", h.entry.ID),
+				})
+				continue
+			}
+			plan.Actions = append(plan.Actions, Action{
+				Kind:   ActionUpdate,
+				ID:     te.ID,
+				Update: &toggl.TimeEntryUpdate{Start: toggl.Time(h.stop), Duration: toggl.Int(int(stop.Sub(h.stop).Seconds()))},
+				Reason: fmt.Sprintf("trimmed to the stop of time entry %d", h.entry.ID),
+			})
+			te.Start = h.stop
+		}
+
+		h = &head{entry: te, stop: stop}
+		heads = append(heads, h)
+		last[key] = h
+	}
+
+	for _, h := range heads {
+		if len(h.merged) == 0 {
+			continue
+		}
+		ids := []string{}
+		for _, id := range h.merged {
+			ids = append(ids, fmt.Sprint(id))
+		}
+		reason := "merging time entries " + strings.Join(ids, ", ")
+		if h.gap > 0 {
+			reason += fmt.Sprintf(", adding %s of untracked gaps", h.gap)
+		}
+		if !h.stop.Equal(h.entry.EffectiveStop(time.Time{})) {
+			plan.Actions = append(plan.Actions, Action{
+				Kind:   ActionUpdate,
+				ID:     h.entry.ID,
+				Update: &toggl.TimeEntryUpdate{Stop: toggl.Time(h.stop), Duration: toggl.Int(int(h.stop.Sub(h.entry.Start).Seconds()))},
+				Reason: reason,
+			})
+		}
+		for _, id := range h.merged {
+			plan.Actions = append(plan.Actions, Action{
+				Kind:   ActionDelete,
+				ID:     id,
+				Reason: fmt.Sprintf("merged into time entry %d", h.entry.ID),
+			})
+		}
+	}
+
+	return plan
+}
+
+// Repair fetches the time entries between start and end, plans their repair
+// and executes the plan unless it is a dry run.
+func Repair(ctx context.Context, api RepairAPI, start, end time.Time, options *RepairOptions) (*Plan, error) {
+	timeEntries, err := api.GetTimeEntries(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := PlanRepair(*timeEntries, options)
+	if options != nil && options.DryRun {
+		return plan, nil
+	}
+
+	_, err = plan.Execute(ctx, api)
+	return plan, err
+}
+
+func repairKey(te toggl.TimeEntry) string {
+	tags := append([]string{}, te.Tags...)
+	sort.Strings(tags)
+	b, _ := json.Marshal([]interface{}{te.Wid, te.Pid, te.Description, tags, te.Billable})
+	return string(b)
+}
@@ -0,0 +1,175 @@
+package timesheet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+type fakeRepairAPI struct {
+	entries []toggl.TimeEntry
+	calls   []string
+	fail    int
+}
+
+func (f *fakeRepairAPI) GetTimeEntries(ctx context.Context, start, end time.Time) (*[]toggl.TimeEntry, error) {
+	return &f.entries, nil
+}
+
+func (f *fakeRepairAPI) PatchTimeEntry(ctx context.Context, id int, update toggl.TimeEntryUpdate) (*toggl.TimeEntry, error) {
+	f.calls = append(f.calls, fmt.Sprintf("update %d", id))
+	if f.fail == id {
+		return nil, errors.New("failed")
+	}
+	return &toggl.TimeEntry{ID: id}, nil
+}
+
+func (f *fakeRepairAPI) DeleteTimeEntry(ctx context.Context, id int) error {
+	f.calls = append(f.calls, fmt.Sprintf("delete %d", id))
+	if f.fail == id {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func repairEntries() []toggl.TimeEntry {
+	at := func(hour, min int) time.Time {
+		return time.Date(2018, 4, 12, hour, min, 0, 0, time.UTC)
+	}
+	entry := func(id int, description string, start, stop time.Time) toggl.TimeEntry {
+		return toggl.TimeEntry{ID: id, Pid: 10, Description: description, Tags: []string{"dev"}, Start: start, Stop: stop, Duration: int(stop.Sub(start).Seconds())}
+	}
+	return []toggl.TimeEntry{
+		entry(1, "work", at(9, 0), at(10, 0)),
+		// Overlapping.
+		entry(2, "work", at(9, 30), at(10, 30)),
+		// Different description, interleaved.
+		entry(3, "meeting", at(10, 0), at(11, 0)),
+		// Adjacent.
+		entry(4, "work", at(10, 30), at(11, 0)),
+		// Within entry 5 after the gap.
+		entry(5, "work", at(13, 0), at(15, 0)),
+		entry(6, "work", at(13, 30), at(14, 0)),
+	}
+}
+
+func TestPlanRepairMerge(t *testing.T) {
+	plan := PlanRepair(repairEntries(), &RepairOptions{Mode: RepairMerge})
+
+	stop := time.Date(2018, 4, 12, 11, 0, 0, 0, time.UTC)
+	expected := []Action{
+		{Kind: ActionUpdate, ID: 1, Update: &toggl.TimeEntryUpdate{Stop: &stop, Duration: toggl.Int(7200)}, Reason: "merging time entries 2, 4"},
+		{Kind: ActionDelete, ID: 2, Reason: "merged into time entry 1"},
+		{Kind: ActionDelete, ID: 4, Reason: "merged into time entry 1"},
+		{Kind: ActionDelete, ID: 6, Reason: "merged into time entry 5"},
+	}
+	if !reflect.DeepEqual(expected, plan.Actions) {
+		t.Fatalf("Expected actions\n%v\ngot\n%v", (&Plan{Actions: expected}).String(), plan.String())
+	}
+}
+
+func TestPlanRepairTrim(t *testing.T) {
+	plan := PlanRepair(repairEntries(), &RepairOptions{Mode: RepairTrim})
+
+	start := time.Date(2018, 4, 12, 10, 0, 0, 0, time.UTC)
+	expected := []Action{
+		{Kind: ActionUpdate, ID: 2, Update: &toggl.TimeEntryUpdate{Start: &start, Duration: toggl.Int(1800)}, Reason: "trimmed to the stop of time entry 1"},
+		{Kind: ActionDelete, ID: 6, Reason: "within time entry 5"},
+	}
+	if !reflect.DeepEqual(expected, plan.Actions) {
+		t.Fatalf("Expected actions\n%v\ngot\n%v", (&Plan{Actions: expected}).String(), plan.String())
+	}
+}
+
+func TestRepair(t *testing.T) {
+	api := &fakeRepairAPI{entries: repairEntries()}
+
+	plan, err := Repair(context.Background(), api, time.Time{}, time.Time{}, &RepairOptions{DryRun: true})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if len(plan.Actions) != 4 || len(api.calls) != 0 {
+		t.Fatalf("Expected a dry run, got calls %v", api.calls)
+	}
+	if !strings.Contains(plan.String(), `PUT time_entries/1 {"stop":"2018-04-12T11:00:00Z","duration":7200}`) {
+		t.Fatalf("Unexpected plan\n%s", plan)
+	}
+
+	api.fail = 4
+	if _, err := Repair(context.Background(), api, time.Time{}, time.Time{}, nil); err == nil {
+		t.Fatal(errors.New("Expected the failed call to stop the repair"))
+	}
+	if len(api.calls) != 3 {
+		t.Fatalf("Expected 3 calls, got %v", api.calls)
+	}
+
+	api.fail, api.calls = 0, nil
+	done, err := plan.Execute(context.Background(), api)
+	if err != nil || done != 4 {
+		t.Fatalf("Unexpected result %d, %v", done, err)
+	}
+}
+
+func TestPlanRepairWithoutStop(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2018, 4, 12, hour, 0, 0, 0, time.UTC)
+	}
+	// Entry 2 has no stop, but runs from 10:00 to 13:00.
+	timeEntries := []toggl.TimeEntry{
+		{ID: 1, Pid: 10, Description: "work", Start: at(9), Stop: at(11), Duration: 7200},
+		{ID: 2, Pid: 10, Description: "work", Start: at(10), Duration: 10800},
+	}
+
+	plan := PlanRepair(timeEntries, &RepairOptions{Mode: RepairTrim})
+	expected := []Action{
+		{Kind: ActionUpdate, ID: 2, Update: &toggl.TimeEntryUpdate{Start: toggl.Time(at(11)), Duration: toggl.Int(7200)}, Reason: "trimmed to the stop of time entry 1"},
+	}
+	if !reflect.DeepEqual(expected, plan.Actions) {
+		t.Fatalf("Expected actions\n%v\ngot\n%v", (&Plan{Actions: expected}).String(), plan.String())
+	}
+
+	plan = PlanRepair(timeEntries, &RepairOptions{Mode: RepairMerge})
+	expected = []Action{
+		{Kind: ActionUpdate, ID: 1, Update: &toggl.TimeEntryUpdate{Stop: toggl.Time(at(13)), Duration: toggl.Int(14400)}, Reason: "merging time entries 2"},
+		{Kind: ActionDelete, ID: 2, Reason: "merged into time entry 1"},
+	}
+	if !reflect.DeepEqual(expected, plan.Actions) {
+		t.Fatalf("Expected actions\n%v\ngot\n%v", (&Plan{Actions: expected}).String(), plan.String())
+	}
+
+	// The same entries reversed, the head having no stop.
+	timeEntries = []toggl.TimeEntry{
+		{ID: 1, Pid: 10, Description: "work", Start: at(9), Duration: 7200},
+		{ID: 2, Pid: 10, Description: "work", Start: at(10), Stop: at(13), Duration: 10800},
+	}
+	plan = PlanRepair(timeEntries, &RepairOptions{Mode: RepairMerge})
+	if len(plan.Actions) != 2 || !plan.Actions[0].Update.Stop.Equal(at(13)) || *plan.Actions[0].Update.Duration != 14400 {
+		t.Fatalf("Unexpected plan\n%s", plan)
+	}
+}
+
+func TestPlanRepairMergeGap(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2018, 4, 12, hour, min, 0, 0, time.UTC)
+	}
+	timeEntries := []toggl.TimeEntry{
+		{ID: 1, Pid: 10, Description: "work", Start: at(9, 0), Stop: at(10, 0), Duration: 3600},
+		{ID: 2, Pid: 10, Description: "work", Start: at(10, 5), Stop: at(11, 0), Duration: 3300},
+	}
+
+	plan := PlanRepair(timeEntries, &RepairOptions{Mode: RepairMerge, MaxGap: 10 * time.Minute})
+	expected := []Action{
+		{Kind: ActionUpdate, ID: 1, Update: &toggl.TimeEntryUpdate{Stop: toggl.Time(at(11, 0)), Duration: toggl.Int(7200)}, Reason: "merging time entries 2, adding 5m0s of untracked gaps"},
+		{Kind: ActionDelete, ID: 2, Reason: "merged into time entry 1"},
+	}
+	if !reflect.DeepEqual(expected, plan.Actions) {
+		t.Fatalf("Expected actions\n%v\ngot\n%v", (&Plan{Actions: expected}).String(), plan.String())
+	}
+}
@@ -0,0 +1,187 @@
+// Package timesheet aggregates and analyses toggl time entries.
+package timesheet
+
+import (
+	"sort"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+// Options configures how time entries are aggregated.
+type Options struct {
+	// Location the days are split in. Defaults to time.Local.
+	Location *time.Location
+	// WeekStart is the first day of the weeks. The zero value is sunday.
+	WeekStart time.Weekday
+	// Now ends the running entries. Defaults to time.Now.
+	Now func() time.Time
+	// Projects resolve the clients of the entries.
+	Projects []toggl.Project
+}
+
+func (o *Options) withDefaults() Options {
+	opts := Options{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return opts
+}
+
+// Segment is the part of a time entry within a single day.
+type Segment struct {
+	Entry toggl.TimeEntry
+	// Day is the midnight starting the day of the segment.
+	Day   time.Time
+	Start time.Time
+	Stop  time.Time
+}
+
+// Duration returns the length of the segment.
+func (s Segment) Duration() time.Duration {
+	return s.Stop.Sub(s.Start)
+}
+
+// Split cuts the time entries at the day boundaries of the location,
+// running entries ending now. Segments are sorted by start.
+func Split(timeEntries []toggl.TimeEntry, options *Options) []Segment {
+	opts := options.withDefaults()
+	now := opts.Now()
+
+	segments := []Segment{}
+	for _, te := range timeEntries {
+		start := te.Start.In(opts.Location)
+		stop := te.EffectiveStop(now).In(opts.Location)
+
+		for start.Before(stop) {
+			day := midnight(start)
+			next := day.AddDate(0, 0, 1)
+			end := stop
+			if next.Before(stop) {
+				end = next
+			}
+			segments = append(segments, Segment{Entry: te, Day: day, Start: start, Stop: end})
+			start = end
+		}
+	}
+
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].Start.Before(segments[j].Start)
+	})
+	return segments
+}
+
+// Totals sums the tracked time of a period.
+// An entry with several tags counts towards each of them, untagged time is
+// counted under the "" tag, and time without project or client under 0.
+type Totals struct {
+	Total     time.Duration
+	Billable  time.Duration
+	ByProject map[int]time.Duration
+	ByClient  map[int]time.Duration
+	ByTag     map[string]time.Duration
+}
+
+func newTotals() Totals {
+	return Totals{
+		ByProject: map[int]time.Duration{},
+		ByClient:  map[int]time.Duration{},
+		ByTag:     map[string]time.Duration{},
+	}
+}
+
+func (t *Totals) add(s Segment, clients map[int]int) {
+	d := s.Duration()
+	t.Total += d
+	if s.Entry.Billable {
+		t.Billable += d
+	}
+	t.ByProject[s.Entry.Pid] += d
+	t.ByClient[clients[s.Entry.Pid]] += d
+	if len(s.Entry.Tags) == 0 {
+		t.ByTag[""] += d
+	}
+	for _, tag := range s.Entry.Tags {
+		t.ByTag[tag] += d
+	}
+}
+
+// Period is a day or a week of a timesheet.
+type Period struct {
+	Start time.Time
+	End   time.Time
+	Totals
+}
+
+// Timesheet holds the totals of every day and week having tracked time.
+type Timesheet struct {
+	Days  []Period
+	Weeks []Period
+	Totals
+}
+
+// Aggregate sums the time entries per day, per week and overall.
+func Aggregate(timeEntries []toggl.TimeEntry, options *Options) *Timesheet {
+	opts := options.withDefaults()
+
+	clients := map[int]int{}
+	for _, p := range opts.Projects {
+		clients[p.ID] = p.Cid
+	}
+
+	sheet := &Timesheet{
+		Days:   []Period{},
+		Weeks:  []Period{},
+		Totals: newTotals(),
+	}
+	days := map[time.Time]int{}
+	weeks := map[time.Time]int{}
+	for _, s := range Split(timeEntries, &opts) {
+		i, ok := days[s.Day]
+		if !ok {
+			i = len(sheet.Days)
+			days[s.Day] = i
+			sheet.Days = append(sheet.Days, Period{Start: s.Day, End: s.Day.AddDate(0, 0, 1), Totals: newTotals()})
+		}
+		sheet.Days[i].add(s, clients)
+
+		week := WeekStart(s.Day, opts.WeekStart)
+		i, ok = weeks[week]
+		if !ok {
+			i = len(sheet.Weeks)
+			weeks[week] = i
+			sheet.Weeks = append(sheet.Weeks, Period{Start: week, End: week.AddDate(0, 0, 7), Totals: newTotals()})
+		}
+		sheet.Weeks[i].add(s, clients)
+
+		sheet.add(s, clients)
+	}
+
+	return sheet
+}
+
+// WeekStart returns the midnight starting the week of t.
+func WeekStart(t time.Time, weekStart time.Weekday) time.Time {
+	day := midnight(t)
+	offset := (int(day.Weekday()) - int(weekStart) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// clockTime returns the wall clock time, given from midnight, on the day of t.
+// Unlike adding it to midnight, it is not shifted on daylight saving changes.
+func clockTime(t time.Time, clock time.Duration) time.Time {
+	y, m, d := t.Date()
+	h, min, sec := int(clock/time.Hour), int(clock%time.Hour/time.Minute), int(clock%time.Minute/time.Second)
+	return time.Date(y, m, d, h, min, sec, int(clock%time.Second), t.Location())
+}
@@ -0,0 +1,103 @@
+package timesheet
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/otms61/toggl"
+)
+
+func TestSplit(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*3600)
+	now := time.Date(2018, 4, 14, 1, 0, 0, 0, tokyo)
+	timeEntries := []toggl.TimeEntry{
+		// 22:00 to 02:00 in Tokyo.
+		{ID: 1, Start: time.Date(2018, 4, 12, 13, 0, 0, 0, time.UTC), Duration: 4 * 3600},
+		// Running since 23:30 in Tokyo.
+		{ID: 2, Start: time.Date(2018, 4, 13, 23, 30, 0, 0, tokyo), Duration: -int(time.Date(2018, 4, 13, 23, 30, 0, 0, tokyo).Unix())},
+	}
+
+	segments := Split(timeEntries, &Options{Location: tokyo, Now: func() time.Time { return now }})
+
+	got := []string{}
+	for _, s := range segments {
+		got = append(got, s.Day.Format("01-02")+" "+s.Start.Format("15:04")+"-"+s.Stop.Format("15:04"))
+	}
+	expected := []string{"04-12 22:00-00:00", "04-13 00:00-02:00", "04-13 23:30-00:00", "04-14 00:00-01:00"}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected segments %v, got %v", expected, got)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	loc := time.UTC
+	at := func(day, hour int) time.Time {
+		return time.Date(2018, 4, day, hour, 0, 0, 0, loc)
+	}
+	timeEntries := []toggl.TimeEntry{
+		// Thursday 12th.
+		{ID: 1, Pid: 10, Start: at(12, 9), Duration: 2 * 3600, Billable: true, Tags: []string{"dev", "fun"}},
+		{ID: 2, Pid: 20, Start: at(12, 23), Duration: 2 * 3600},
+		// Sunday 15th and Monday 16th.
+		{ID: 3, Start: at(15, 10), Duration: 3600, Tags: []string{"dev"}},
+		{ID: 4, Pid: 10, Start: at(16, 10), Duration: -int(at(16, 10).Unix()), Billable: true},
+	}
+	opts := &Options{
+		Location:  loc,
+		WeekStart: time.Monday,
+		Now:       func() time.Time { return at(16, 13) },
+		Projects:  []toggl.Project{{ID: 10, Cid: 100}, {ID: 20, Cid: 200}},
+	}
+
+	sheet := Aggregate(timeEntries, opts)
+
+	days := map[string]time.Duration{}
+	for _, d := range sheet.Days {
+		days[d.Start.Format("01-02")] = d.Total
+	}
+	expectedDays := map[string]time.Duration{
+		"04-12": 3 * time.Hour,
+		"04-13": time.Hour,
+		"04-15": time.Hour,
+		"04-16": 3 * time.Hour,
+	}
+	if !reflect.DeepEqual(expectedDays, days) {
+		t.Fatalf("Expected days %v, got %v", expectedDays, days)
+	}
+
+	if len(sheet.Weeks) != 2 || !sheet.Weeks[0].Start.Equal(at(9, 0)) || sheet.Weeks[0].Total != 5*time.Hour || sheet.Weeks[1].Total != 3*time.Hour {
+		t.Fatalf("Unexpected weeks %+v", sheet.Weeks)
+	}
+
+	expected := Totals{
+		Total:     8 * time.Hour,
+		Billable:  5 * time.Hour,
+		ByProject: map[int]time.Duration{0: time.Hour, 10: 5 * time.Hour, 20: 2 * time.Hour},
+		ByClient:  map[int]time.Duration{0: time.Hour, 100: 5 * time.Hour, 200: 2 * time.Hour},
+		ByTag:     map[string]time.Duration{"": 5 * time.Hour, "dev": 3 * time.Hour, "fun": 2 * time.Hour},
+	}
+	if !reflect.DeepEqual(expected, sheet.Totals) {
+		t.Fatalf("Expected totals %+v, got %+v", expected, sheet.Totals)
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	thursday := time.Date(2018, 4, 12, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		weekStart time.Weekday
+		expected  time.Time
+	}{
+		{time.Sunday, time.Date(2018, 4, 8, 0, 0, 0, 0, time.UTC)},
+		{time.Monday, time.Date(2018, 4, 9, 0, 0, 0, 0, time.UTC)},
+		{time.Thursday, time.Date(2018, 4, 12, 0, 0, 0, 0, time.UTC)},
+		{time.Friday, time.Date(2018, 4, 6, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		if got := WeekStart(thursday, test.weekStart); !got.Equal(test.expected) {
+			t.Errorf("%s: expected %s, got %s", test.weekStart, test.expected, got)
+		}
+	}
+}
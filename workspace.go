@@ -1,7 +1,9 @@
 package toggl
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -43,6 +45,21 @@ type WorkspaceUser struct {
 	AvatarFileName string    `json:"avatar_file_name"`
 }
 
+// WorkspaceUpdate is used to partially update a workspace. Only the non-nil fields are sent.
+type WorkspaceUpdate struct {
+	Name                        *string `json:"name,omitempty"`
+	DefaultHourlyRate           *int    `json:"default_hourly_rate,omitempty"`
+	DefaultCurrency             *string `json:"default_currency,omitempty"`
+	OnlyAdminsMayCreateProjects *bool   `json:"only_admins_may_create_projects,omitempty"`
+	OnlyAdminsSeeBillableRates  *bool   `json:"only_admins_see_billable_rates,omitempty"`
+	Rounding                    *int    `json:"rounding,omitempty"`
+	RoundingMinutes             *int    `json:"rounding_minutes,omitempty"`
+}
+
+type workspaceResponse struct {
+	Data Workspace `json:"data"`
+}
+
 // GetWrokspaces will retrive the all workspace of the token onwner.
 func (c *Client) GetWrokspaces(ctx context.Context) (*[]Workspace, error) {
 	spath := "v8/workspaces"
@@ -94,3 +111,26 @@ func (c *Client) GetWorkspaceUsers(ctx context.Context, id int) (*[]WorkspaceUse
 
 	return response, nil
 }
+
+// PatchWorkspace updates the fields of the workspace set in the update.
+func (c *Client) PatchWorkspace(ctx context.Context, id int, update WorkspaceUpdate) (*Workspace, error) {
+	spath := fmt.Sprintf("v8/workspaces/%d", id)
+	response := &workspaceResponse{}
+
+	params := struct {
+		Workspace WorkspaceUpdate `json:"workspace"`
+	}{
+		Workspace: update,
+	}
+	j, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.put(ctx, spath, bytes.NewBuffer(j), response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
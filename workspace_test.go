@@ -186,3 +186,52 @@ func TestGetWorkspaceUsers(t *testing.T) {
 		t.Fatal(errors.New("Response is incorrect"))
 	}
 }
+
+func TestPatchWorkspace(t *testing.T) {
+	expectedURL := "/api/v8/workspaces/3278506"
+	expectedBody := `{"workspace":{"default_currency":"USD","rounding":1,"rounding_minutes":0}}`
+	expected := getTestWorkspace()
+
+	client := newMockClient(func(req *http.Request) (*http.Response, error) {
+		if !strings.HasPrefix(req.URL.Path, expectedURL) {
+			return nil, fmt.Errorf("Expected URL '%s', got %s", expectedURL, req.URL.Path)
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if string(body) != expectedBody {
+			return nil, fmt.Errorf("Expected body '%s', got %s", expectedBody, body)
+		}
+
+		b, err := json.Marshal(struct {
+			Data Workspace `json:"data"`
+		}{
+			Data: getTestWorkspace(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(b)),
+		}, nil
+
+	})
+	api := New("test", OptionHTTPClient(client))
+
+	workspace, err := api.PatchWorkspace(context.Background(), 3278506, WorkspaceUpdate{
+		DefaultCurrency: String("USD"),
+		Rounding:        Int(1),
+		RoundingMinutes: Int(0),
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if !reflect.DeepEqual(expected, *workspace) {
+		t.Fatal(errors.New("Response is incorrect"))
+	}
+}